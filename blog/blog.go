@@ -3,21 +3,33 @@ package blog
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"html/template"
 	"io"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/cmj0121/gitup/config"
-	"github.com/gomarkdown/markdown"
-	"github.com/gomarkdown/markdown/html"
-	"github.com/gomarkdown/markdown/parser"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// the shortcut of the link to the blog/markdown source, like ref:foo
+	REF_PREFIX = "ref:"
+)
+
+var (
+	// the link in the rendered HTML
+	RE_HREF = regexp.MustCompile(`(<a\s[^>]*?\bhref=")([^"]*)(")`)
+)
+
 // the blog/post instance
 type Blog struct {
 	// the source blog/markdown filepath
@@ -32,14 +44,35 @@ type Blog struct {
 	// the description of the blog
 	Description string `kong:"-"`
 
+	// the optional front matter of the blog/markdown
+	Meta FrontMatter `kong:"-"`
+
+	// the author and the output name prefix, overridden by the source
+	// repository when aggregated
+	Author string `kong:"-"`
+	Prefix string `kong:"-"`
+
+	// the layout of the theme, the blog layout if empty
+	Layout string `kong:"-"`
+
 	// the blogs timestamp
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
-	md   []byte // the raw markdown context
-	html []byte // the raw HTML page
+	md       []byte         // the raw markdown context
+	offset   int            // the lines of the front matter before the markdown
+	engine   string         // the engine required by the source format
+	html     []byte         // the raw HTML page
+	conf     *config.Config // the config of the rendering, optional
+	includer Includer       // read the included files of the repository
+	toc      []*Heading     // the structured table of contents
+	words    int            // the number of words
 }
 
+// resolve the link to the other blog/markdown source, like ../2022/foo.md or
+// ref:foo, into the output link, the source is the path of the linking blog
+type Resolver func(source, link string) (resolved string, ok bool)
+
 // create the blog from the open file
 func New(reader io.Reader) (blog *Blog, err error) {
 	var buff bytes.Buffer
@@ -52,8 +85,11 @@ func New(reader io.Reader) (blog *Blog, err error) {
 		return
 	}
 
-	blog = &Blog{
-		md: buff.Bytes(),
+	blog = &Blog{}
+	if err = blog.load(buff.Bytes()); err != nil {
+		// cannot load the blog/markdown
+		blog = nil
+		return
 	}
 
 	return
@@ -81,11 +117,84 @@ func (blog *Blog) Run(config *config.Config) (err error) {
 		return
 	}
 
-	blog.md = buff.Bytes()
+	format, ok := FindFormat(config, blog.Path)
+	if !ok {
+		// the blog/markdown by default
+		format = Markdown{}
+	}
+
+	var text []byte
+	if text, err = format.Convert(blog.Path, buff.Bytes()); err != nil {
+		log.WithFields(log.Fields{
+			"path":  blog.Path,
+			"error": err,
+		}).Warn("cannot convert blog")
+		return
+	}
+
+	blog.engine = format.Engine()
+	if err = blog.load(text); err != nil {
+		log.WithFields(log.Fields{
+			"path":  blog.Path,
+			"error": err,
+		}).Warn("cannot load blog")
+		return
+	}
+
+	if err = config.LoadData(); err != nil {
+		// invalid data files in the current folder
+		return
+	}
+
+	blog.SetConfig(config)
+	blog.SetIncluder(blog.workdir_includer())
 	err = blog.Write(config, nil)
 	return
 }
 
+// read the included files from the current folder, which is treated as the
+// repository of the single blog/markdown
+func (blog *Blog) workdir_includer() (includer Includer) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		// cannot include any file without the current folder
+		return
+	}
+
+	dir_includer := DirIncluder(cwd)
+	includer = func(name string) (data []byte, err error) {
+		if filepath.IsAbs(name) {
+			// the blog/markdown path is absolute
+			if name, err = filepath.Rel(cwd, name); err != nil {
+				return
+			}
+			name = filepath.ToSlash(name)
+		}
+
+		data, err = dir_includer(name)
+		return
+	}
+	return
+}
+
+// load the raw text and split the optional front matter
+func (blog *Blog) load(text []byte) (err error) {
+	if blog.Meta, blog.md, err = SplitFrontMatter(text); err != nil {
+		// invalid front matter
+		return
+	}
+	if bytes.HasSuffix(text, blog.md) {
+		blog.offset = bytes.Count(text[:len(text)-len(blog.md)], []byte("\n"))
+	}
+
+	if blog.Title == "" {
+		// the customized title from the front matter
+		blog.Title = blog.Meta.Title
+	}
+	blog.Description = blog.Meta.Description
+	return
+}
+
 func (blog *Blog) Dup() (dup *Blog) {
 	dup = &Blog{
 		Path: blog.Path,
@@ -93,16 +202,31 @@ func (blog *Blog) Dup() (dup *Blog) {
 		Output:      blog.Output,
 		Title:       blog.Title,
 		Description: blog.Description,
+		Meta:        blog.Meta,
+		Author:      blog.Author,
+		Prefix:      blog.Prefix,
 
 		CreatedAt: blog.CreatedAt,
 		UpdatedAt: blog.UpdatedAt,
 
-		md:   blog.md,
-		html: blog.html,
+		md:     blog.md,
+		offset: blog.offset,
+		engine: blog.engine,
+		html:   blog.html,
+		conf:   blog.conf,
+		toc:    blog.toc,
+
+		includer: blog.includer,
+		words:    blog.words,
 	}
 	return
 }
 
+// set the config of the rendering
+func (blog *Blog) SetConfig(conf *config.Config) {
+	blog.conf = conf
+}
+
 // render the blog from markdown to HTML page
 func (blog *Blog) Render(config *config.Config) (text []byte, err error) {
 	if _, err = blog.RenderHTML(); err != nil {
@@ -117,28 +241,32 @@ func (blog *Blog) Render(config *config.Config) (text []byte, err error) {
 // render the raw HTML from markdown
 func (blog *Blog) RenderHTML() (text []byte, err error) {
 	if text = blog.html; len(text) == 0 {
-		// the parser settings
-		extensions := parser.CommonExtensions | parser.AutoHeadingIDs
-		extensions |= parser.Titleblock
-		extensions |= parser.Footnotes
-		extensions |= parser.SuperSubscript
-		extensions |= parser.Mmark
-
-		parser := parser.NewWithExtensions(extensions)
+		var renderer Renderer
+		if renderer, err = blog.renderer(); err != nil {
+			// unknown markdown engine
+			return
+		}
 
-		// the render settings
-		htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.TOC | html.LazyLoadImages
-		htmlFlags |= html.NofollowLinks | html.NoreferrerLinks | html.NoopenerLinks
+		var source *Blog
+		var shortcodes *shortcode_expander
+		if source, shortcodes, err = blog.expand_shortcodes(renderer); err != nil {
+			// invalid shortcode
+			return
+		}
 
-		opts := html.RendererOptions{Flags: htmlFlags}
-		render := html.NewRenderer(opts)
+		var rendered Rendered
+		if rendered, err = renderer.Render(source); err != nil {
+			// cannot render by the markdown engine
+			return
+		}
 
-		text = markdown.ToHTML(blog.md, parser, render)
+		blog.toc, blog.words = rendered.TOC, rendered.Words
+		text = shortcodes.fill(rendered.HTML)
 		blog.html = text
 
 		// find the post title
 		if blog.Title == "" {
-			RE_TITLE := regexp.MustCompile(`<h1 id=.*?>([\s\S]+?)</h1>`)
+			RE_TITLE := regexp.MustCompile(`<h1(?:\s[^>]*)?>([\s\S]+?)</h1>`)
 
 			if text := blog.html; RE_TITLE.Match(text) {
 				// find the title
@@ -152,7 +280,7 @@ func (blog *Blog) RenderHTML() (text []byte, err error) {
 		}
 
 		RE_DESC := regexp.MustCompile(`<blockquote>\s*(:?<.*?>)*\s*([^<]+?)\s*(:?<.*?>)*\s*</blockquote>`)
-		if RE_DESC.Match(text) {
+		if blog.Description == "" && RE_DESC.Match(text) {
 			// find the description
 			blog.Description = string(RE_DESC.FindAllSubmatch(text, -1)[0][2])
 		}
@@ -161,6 +289,65 @@ func (blog *Blog) RenderHTML() (text []byte, err error) {
 	return
 }
 
+// resolve the links to the other blog/markdown sources in the rendered HTML,
+// the blog is never rendered again, and return the links cannot be resolved
+func (blog *Blog) Resolve(resolver Resolver) (unresolved []string, err error) {
+	if _, err = blog.RenderHTML(); err != nil {
+		// cannot render the HTML
+		return
+	}
+
+	if resolver == nil {
+		// nothing to resolve
+		return
+	}
+
+	blog.html = RE_HREF.ReplaceAllFunc(blog.html, func(matched []byte) []byte {
+		parts := RE_HREF.FindSubmatch(matched)
+
+		link := html.UnescapeString(string(parts[2]))
+		if unescaped, err := url.PathUnescape(link); err == nil {
+			// the link may be percent-encoded by the markdown engine
+			link = unescaped
+		}
+		if !IsRef(link) {
+			// not the link to the blog/markdown source
+			return matched
+		}
+
+		resolved, ok := resolver(blog.Path, link)
+		if !ok {
+			unresolved = append(unresolved, link)
+			return matched
+		}
+
+		return bytes.Join([][]byte{parts[1], []byte(html.EscapeString(resolved)), parts[3]}, nil)
+	})
+	return
+}
+
+// the link refers to the blog/markdown source, the ref: shortcut or the
+// related path with the .md or .markdown extension
+func IsRef(link string) (ok bool) {
+	if strings.HasPrefix(link, REF_PREFIX) {
+		ok = true
+		return
+	}
+
+	if idx := strings.IndexAny(link, "?#"); idx >= 0 {
+		link = link[:idx]
+	}
+
+	switch {
+	case strings.Contains(link, ":"), strings.HasPrefix(link, "//"):
+		// the external link
+	default:
+		ext := strings.ToLower(path.Ext(link))
+		ok = ext == ".md" || ext == ".markdown"
+	}
+	return
+}
+
 // write blog to destination
 func (blog *Blog) Write(conf *config.Config, summary Summary) (err error) {
 	var writer io.Writer
@@ -191,7 +378,7 @@ func (blog *Blog) Write(conf *config.Config, summary Summary) (err error) {
 	}
 
 	var tmpl *template.Template
-	if tmpl, err = conf.Template(); err != nil {
+	if tmpl, err = conf.LayoutTemplate(blog.Layout); err != nil {
 		// cannot get the template from the config
 		return
 	}
@@ -222,6 +409,26 @@ func (blog Blog) UID() (uid string) {
 	return
 }
 
+// the output name without the extension before the collision is resolved,
+// the basename with the UID unless hidden or disabled, and the prefix of the
+// aggregated repository
+func (blog Blog) Name(conf *config.Config) (name string) {
+	basename := filepath.Base(filepath.Clean(blog.Path))
+	basename = basename[:len(basename)-len(filepath.Ext(basename))]
+
+	switch conf.IsHidden(blog.Path) || conf.DisabledTimestampPrefix {
+	case true:
+		name = basename
+	case false:
+		name = fmt.Sprintf("%v-%v", blog.UID(), basename)
+	}
+
+	if blog.Prefix != "" {
+		name = fmt.Sprintf("%v-%v", blog.Prefix, name)
+	}
+	return
+}
+
 // the rendered HTML
 func (blog Blog) HTML() (html string) {
 	html = string(blog.html)
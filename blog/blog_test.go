@@ -8,6 +8,8 @@ import (
 	"testing"
 	"testing/iotest"
 	"time"
+
+	"github.com/cmj0121/gitup/config"
 )
 
 var test_markdown = `
@@ -76,3 +78,91 @@ func TestBlogsSort(t *testing.T) {
 		t.Errorf("expect sort to %v: %v", Blogs{y, x}, blogs)
 	}
 }
+
+func TestFrontMatter(t *testing.T) {
+	text := "---\ntitle: The front matter\ndate: 2022-01-02\n---\n# The mock markdown post #\n"
+
+	blog, err := New(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("cannot create blog: %v", err)
+	}
+
+	if blog.Title != "The front matter" {
+		t.Errorf("expect title from front matter: %v", blog.Title)
+	}
+
+	if date := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC); !blog.Meta.Date.Equal(date) {
+		t.Errorf("expect date %v: %v", date, blog.Meta.Date)
+	}
+
+	if string(blog.md) != "# The mock markdown post #\n" {
+		t.Errorf("expect front matter be removed: %q", blog.md)
+	}
+
+	// the horizontal rule is not the front matter
+	if _, md, err := SplitFrontMatter([]byte("---\n\ntext\n")); err != nil || string(md) != "---\n\ntext\n" {
+		t.Errorf("expect not front matter: %q %v", md, err)
+	}
+
+	if _, err := New(strings.NewReader("---\n: invalid\n---\n")); err == nil {
+		t.Errorf("expect invalid front matter")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	text := "# Resolve\n\n[foo](../foo.md#intro) [diary](日記.md) [bar](ref:bar) [missing](missing.md) [site](https://example.com/a.md)\n\n" +
+		"{{< include snip.txt >}}\n\n`[code](foo.md)`\n"
+
+	resolver := func(source, link string) (resolved string, ok bool) {
+		resolved, ok = map[string]string{
+			"../foo.md#intro": "foo.htm#intro",
+			"日記.md":           "diary.htm",
+			"ref:bar":         "bar.htm?a=1&b=2",
+		}[link]
+		return
+	}
+
+	conf := &config.Config{}
+	for _, engine := range []string{"gomarkdown", "gfm"} {
+		conf.Markdown.Engine = engine
+
+		included := 0
+		blog, _ := New(strings.NewReader(text))
+		blog.Path = "posts/resolve.md"
+		blog.SetConfig(conf)
+		blog.SetIncluder(func(name string) (data []byte, err error) {
+			included++
+			data = []byte("snippet\n")
+			return
+		})
+
+		if _, err := blog.RenderHTML(); err != nil {
+			t.Fatalf("%v cannot render: %v", engine, err)
+		}
+
+		unresolved, err := blog.Resolve(resolver)
+		if err != nil {
+			t.Fatalf("%v cannot resolve: %v", engine, err)
+		}
+		if fmt.Sprint(unresolved) != "[missing.md]" {
+			t.Errorf("%v expect the missing.md unresolved: %v", engine, unresolved)
+		}
+		if included != 1 {
+			t.Errorf("%v expect the blog rendered once: %d", engine, included)
+		}
+
+		html := string(blog.html)
+		for _, expect := range []string{
+			`href="foo.htm#intro"`,
+			`href="diary.htm"`,
+			`href="bar.htm?a=1&amp;b=2"`,
+			`href="missing.md"`,
+			`href="https://example.com/a.md"`,
+			`[code](foo.md)`,
+		} {
+			if !strings.Contains(html, expect) {
+				t.Errorf("%v expect %v in the HTML:\n%v", engine, expect, html)
+			}
+		}
+	}
+}
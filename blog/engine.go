@@ -0,0 +1,99 @@
+package blog
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+const (
+	// the default markdown engine
+	ENGINE_DEFAULT = "gomarkdown"
+	// the raw HTML engine of the HTML source
+	ENGINE_HTML = "html"
+)
+
+// the registered markdown engines by name, selected by the engine of the
+// markdown config or the front matter
+var RENDERERS = map[string]Renderer{
+	"gomarkdown": GoMarkdown{},
+	"gfm":        GFM{},
+	ENGINE_HTML:  RawHTML{},
+}
+
+func init() {
+	config.MarkdownRenderer = render_snippet
+}
+
+// the markdown engine which renders the blog/markdown into the raw HTML
+type Renderer interface {
+	Render(blog *Blog) (rendered Rendered, err error)
+}
+
+// the result of the markdown engine
+type Rendered struct {
+	// the raw HTML of the blog
+	HTML []byte
+	// the structured table of contents
+	TOC []*Heading
+	// the number of words, each CJK character is one word
+	Words int
+}
+
+// the markdown config of the blog, the config overridden by the front matter
+func (blog *Blog) markdown_overrides() (overrides []config.Markdown) {
+	overrides = []config.Markdown{blog.Meta.Markdown}
+	if blog.conf != nil {
+		overrides = []config.Markdown{blog.conf.Markdown, blog.Meta.Markdown}
+	}
+	return
+}
+
+// the markdown engine of the blog, the one of the source format first and
+// the default one if not set
+func (blog *Blog) renderer() (renderer Renderer, err error) {
+	engine := ENGINE_DEFAULT
+	for _, override := range blog.markdown_overrides() {
+		if override.Engine != "" {
+			engine = override.Engine
+		}
+	}
+	if blog.engine != "" {
+		// the engine required by the source format
+		engine = blog.engine
+	}
+
+	var ok bool
+	if renderer, ok = RENDERERS[engine]; !ok {
+		err = fmt.Errorf("unknown markdown engine: %v", engine)
+		return
+	}
+	return
+}
+
+// render the markdown snippet of the template by the same engine, extensions
+// and flags of the site without the inline TOC
+func render_snippet(conf *config.Config, text string) (html template.HTML, err error) {
+	snippet := &Blog{md: []byte(text), conf: conf}
+	snippet.Meta.Markdown.Flags = map[string]bool{"toc": false}
+
+	var renderer Renderer
+	if renderer, err = snippet.renderer(); err != nil {
+		// unknown markdown engine
+		return
+	}
+	if _, ok := renderer.(RawHTML); ok {
+		// the markdown snippet is never the raw HTML
+		renderer = GoMarkdown{}
+	}
+
+	var rendered Rendered
+	if rendered, err = renderer.Render(snippet); err != nil {
+		// cannot render the markdown snippet
+		return
+	}
+
+	html = template.HTML(rendered.HTML)
+	return
+}
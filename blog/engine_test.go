@@ -0,0 +1,143 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+// the minimal pass rate of the CommonMark spec examples by engine, the
+// gomarkdown is not CommonMark compliant and only keeps the baseline
+var commonmark_pass_rate = map[string]float64{
+	"gfm":        1.00,
+	"gomarkdown": 0.35,
+}
+
+func TestCommonMark(t *testing.T) {
+	data, err := os.ReadFile("testdata/commonmark.json")
+	if err != nil {
+		t.Fatalf("cannot read the CommonMark spec: %v", err)
+	}
+
+	var examples []struct {
+		Markdown string
+		HTML     string
+		Example  int
+		Section  string
+	}
+	if err := json.Unmarshal(data, &examples); err != nil {
+		t.Fatalf("invalid CommonMark spec: %v", err)
+	}
+
+	// the plain CommonMark, disable all the extensions and the flags
+	conf := &config.Config{}
+	conf.Markdown.Extensions = map[string]bool{}
+	conf.Markdown.Flags = map[string]bool{"use_xhtml": true}
+	for name := range EXTENSIONS {
+		conf.Markdown.Extensions[name] = false
+	}
+	for name := range HTML_FLAGS {
+		conf.Markdown.Flags[name] = name == "use_xhtml"
+	}
+
+	for engine, rate := range commonmark_pass_rate {
+		passed := 0
+		for _, example := range examples {
+			blog := &Blog{md: []byte(example.Markdown), conf: conf}
+
+			rendered, err := RENDERERS[engine].Render(blog)
+			switch {
+			case err != nil:
+				t.Errorf("%v cannot render example %v: %v", engine, example.Example, err)
+			case bytes.Equal(bytes.TrimSpace(rendered.HTML), bytes.TrimSpace([]byte(example.HTML))):
+				passed++
+			case rate == 1:
+				t.Errorf("%v fails example %v (%v):\n%q\nexpect:\n%q", engine, example.Example, example.Section, rendered.HTML, example.HTML)
+			}
+		}
+
+		t.Logf("%v passes %v/%v CommonMark examples", engine, passed, len(examples))
+		if float64(passed) < rate*float64(len(examples)) {
+			t.Errorf("%v passes %v/%v CommonMark examples, expect at least %v", engine, passed, len(examples), rate)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	text := "# Title\n> the description\n\n## First\n\n" +
+		"[internal](other.htm) [external](https://example.com/) ![image](a.png) ~~gone~~\n\n" +
+		"- [x] done\n\n| a | b |\n|---|---|\n| 1 | 2 |\n"
+
+	conf := &config.Config{}
+	conf.BaseURL = "https://blog.example.com/"
+	conf.Markdown.Engine = "gfm"
+
+	blog, err := New(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("cannot create blog: %v", err)
+	}
+	blog.SetConfig(conf)
+
+	data, err := blog.RenderHTML()
+	if err != nil {
+		t.Fatalf("cannot render by gfm: %v", err)
+	}
+
+	html := string(data)
+	for _, expect := range []string{
+		`<nav>`,
+		`<h1 id="title">Title</h1>`,
+		`<a href="other.htm">internal</a>`,
+		`<a href="https://example.com/" target="_blank" rel="nofollow noreferrer noopener">external</a>`,
+		`<img src="a.png" alt="image" loading="lazy">`,
+		`<del>gone</del>`,
+		`<input checked="" disabled="" type="checkbox"`,
+		`<table>`,
+	} {
+		if !strings.Contains(html, expect) {
+			t.Errorf("expect %v in the HTML:\n%v", expect, html)
+		}
+	}
+
+	if blog.Title != "Title" || blog.Description != "the description" {
+		t.Errorf("unexpected title %v and description %v", blog.Title, blog.Description)
+	}
+	if toc := blog.TOC(); len(toc) != 1 || toc[0].ID != "title" || len(toc[0].Children) != 1 {
+		t.Errorf("unexpected TOC: %+v", toc)
+	}
+
+	// the front matter overrides the engine of the config
+	blog, _ = New(strings.NewReader("---\nmarkdown:\n  engine: unknown\n---\n" + text))
+	blog.SetConfig(conf)
+	if _, err := blog.RenderHTML(); err == nil {
+		t.Errorf("expect the unknown engine fails")
+	}
+}
+
+func TestMarkdownFunc(t *testing.T) {
+	conf := &config.Config{}
+	conf.BaseURL = "https://blog.example.com"
+	conf.Markdown.Engine = "gfm"
+	conf.Markdown.Flags = map[string]bool{"use_xhtml": false}
+
+	tmpl, err := template.New("test").Funcs(conf.FuncMap()).Parse(`{{ markdown "~~old~~ [site](https://blog.example.com/a.htm) [ext](https://example.com/)" }}`)
+	if err != nil {
+		t.Fatalf("cannot parse the template: %v", err)
+	}
+
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, nil); err != nil {
+		t.Fatalf("cannot render the markdown snippet: %v", err)
+	}
+
+	expect := `<p><del>old</del> <a href="https://blog.example.com/a.htm">site</a> ` +
+		`<a href="https://example.com/" target="_blank" rel="nofollow noreferrer noopener">ext</a></p>`
+	if html := strings.TrimSpace(buff.String()); html != expect {
+		t.Errorf("expect the snippet rendered as the post:\n%v\nbut got:\n%v", expect, html)
+	}
+}
@@ -0,0 +1,162 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the timeout of the external converter command
+	CONVERTER_TIMEOUT = time.Minute
+)
+
+// the registered source formats by the extension, the external converters
+// in the config take precedence
+var FORMATS = map[string]Format{
+	".md":       Markdown{},
+	".markdown": Markdown{},
+	".htm":      HTMLFragment{},
+	".html":     HTMLFragment{},
+	".ipynb":    Notebook{},
+}
+
+// the source format which converts the source into the markup rendered by
+// the engine, like the notebook into the blog/markdown
+type Format interface {
+	// convert the source, the path is the related path of the source
+	Convert(path string, text []byte) (converted []byte, err error)
+
+	// the engine of the converted source, empty for the configured one
+	Engine() string
+}
+
+// find the source format by the extension of the path
+func FindFormat(conf *config.Config, name string) (format Format, ok bool) {
+	ext := strings.ToLower(path.Ext(name))
+
+	if conf != nil {
+		var converter config.Converter
+		if converter, ok = conf.Formats[ext]; ok {
+			format = Command{Converter: converter}
+			return
+		}
+	}
+
+	format, ok = FORMATS[ext]
+	return
+}
+
+// create the blog from the open file in the source format
+func NewWithFormat(reader io.Reader, path string, format Format) (blog *Blog, err error) {
+	var buff bytes.Buffer
+
+	if _, err = io.Copy(&buff, reader); err != nil {
+		// cannot read and save to buffer
+		log.WithFields(log.Fields{
+			"path":  path,
+			"error": err,
+		}).Warn("cannot read blog")
+		return
+	}
+
+	var text []byte
+	if text, err = format.Convert(path, buff.Bytes()); err != nil {
+		// cannot convert the source
+		err = fmt.Errorf("cannot convert %v: %v", path, err)
+		return
+	}
+
+	blog = &Blog{Path: path, engine: format.Engine()}
+	if err = blog.load(text); err != nil {
+		// cannot load the converted source
+		blog = nil
+		return
+	}
+
+	return
+}
+
+// the blog/markdown, rendered as-is
+type Markdown struct{}
+
+// keep the blog/markdown as-is
+func (format Markdown) Convert(path string, text []byte) (converted []byte, err error) {
+	converted = text
+	return
+}
+
+// the configured markdown engine
+func (format Markdown) Engine() (engine string) {
+	return
+}
+
+// the plain HTML fragment with the optional front matter, rendered as-is
+type HTMLFragment struct{}
+
+// keep the HTML fragment as-is
+func (format HTMLFragment) Convert(path string, text []byte) (converted []byte, err error) {
+	converted = text
+	return
+}
+
+// the raw HTML engine
+func (format HTMLFragment) Engine() (engine string) {
+	engine = ENGINE_HTML
+	return
+}
+
+// the external converter command, like asciidoctor or pandoc
+type Command struct {
+	config.Converter
+}
+
+// run the command with the source as the stdin, and the GITUP_SOURCE as the
+// related path of the source
+func (format Command) Convert(path string, text []byte) (converted []byte, err error) {
+	if len(format.Command) == 0 {
+		err = fmt.Errorf("the command is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CONVERTER_TIMEOUT)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, format.Command[0], format.Command[1:]...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GITUP_SOURCE=%v", path))
+	cmd.Stdin = bytes.NewReader(text)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.WithFields(log.Fields{
+		"path":    path,
+		"command": format.Command,
+	}).Debug("run the external converter")
+
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("%v: %v %v", format.Command[0], err, strings.TrimSpace(stderr.String()))
+		return
+	}
+
+	converted = stdout.Bytes()
+	return
+}
+
+// the raw HTML engine, or the configured one for the markdown output
+func (format Command) Engine() (engine string) {
+	if format.Output != config.CONVERTER_OUTPUT_MARKDOWN {
+		engine = ENGINE_HTML
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+var test_notebook = `{
+  "metadata": {"language_info": {"name": "python"}},
+  "nbformat": 4,
+  "cells": [
+    {"cell_type": "markdown", "source": ["# The Notebook\n", "> the description\n"]},
+    {"cell_type": "code", "source": "print('hello')", "outputs": [
+      {"output_type": "stream", "name": "stdout", "text": ["hello\n"]},
+      {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo=\n", "text/plain": ["<Figure>"]}}
+    ]},
+    {"cell_type": "code", "source": "1 / 0", "outputs": [
+      {"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero"}
+    ]}
+  ]
+}`
+
+func TestFormat(t *testing.T) {
+	conf := &config.Config{}
+	conf.Formats = map[string]config.Converter{
+		".adoc": {Command: []string{"sed", "s/^= /# /"}, Output: config.CONVERTER_OUTPUT_MARKDOWN},
+	}
+
+	cases := []struct {
+		Path   string
+		Text   string
+		Title  string
+		Expect []string
+	}{
+		{
+			Path:   "posts/notebook.ipynb",
+			Text:   test_notebook,
+			Title:  "The Notebook",
+			Expect: []string{`<code class="language-python">print('hello')`, "hello\n</code>", `src="data:image/png;base64,iVBORw0KGgo="`, "ZeroDivisionError: division by zero"},
+		},
+		{
+			Path:   "posts/fragment.html",
+			Text:   "---\ndescription: the fragment\n---\n<h1>The Fragment</h1>\n<p>kept *as-is*</p>\n",
+			Title:  "The Fragment",
+			Expect: []string{"<p>kept *as-is*</p>"},
+		},
+		{
+			Path:   "posts/asciidoc.adoc",
+			Text:   "= The AsciiDoc\n\nconverted by the command\n",
+			Title:  "The AsciiDoc",
+			Expect: []string{"<p>converted by the command</p>"},
+		},
+	}
+
+	for _, c := range cases {
+		format, ok := FindFormat(conf, c.Path)
+		if !ok {
+			t.Fatalf("expect the format of %v", c.Path)
+		}
+
+		blog, err := NewWithFormat(strings.NewReader(c.Text), c.Path, format)
+		if err != nil {
+			t.Fatalf("cannot create %v: %v", c.Path, err)
+		}
+		blog.SetConfig(conf)
+
+		html, err := blog.RenderHTML()
+		if err != nil {
+			t.Fatalf("cannot render %v: %v", c.Path, err)
+		}
+		if blog.Title != c.Title {
+			t.Errorf("unexpected title of %v: %v", c.Path, blog.Title)
+		}
+		for _, expect := range c.Expect {
+			if !strings.Contains(string(html), expect) {
+				t.Errorf("expect %v in %v:\n%s", expect, c.Path, html)
+			}
+		}
+	}
+
+	// the headings and the words of the HTML fragment
+	blog, _ := NewWithFormat(strings.NewReader("<h1 id=\"a\">A</h1><h2>B <code>c</code></h2><pre>skipped words</pre>"), "a.htm", HTMLFragment{})
+	blog.RenderHTML() // nolint
+	if toc := blog.TOC(); len(toc) != 1 || toc[0].ID != "a" || len(toc[0].Children) != 1 || toc[0].Children[0].Title != "B c" {
+		t.Errorf("unexpected TOC: %+v", toc)
+	}
+	if words := blog.WordCount(); words != 3 {
+		t.Errorf("unexpected word count: %v", words)
+	}
+
+	if _, ok := FindFormat(conf, "posts/image.png"); ok {
+		t.Errorf("expect no format of the image")
+	}
+
+	format := Command{Converter: config.Converter{Command: []string{"false"}}}
+	if _, err := NewWithFormat(strings.NewReader("text"), "fail.adoc", format); err == nil {
+		t.Errorf("expect the failed command fails")
+	}
+}
@@ -0,0 +1,68 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// the delimiter of the front matter
+	FRONT_MATTER_DELIMITER = []byte("---")
+)
+
+// the optional YAML front matter placed at the top of the blog/markdown
+type FrontMatter struct {
+	// the customized title and description
+	Title       string `yaml:"title,omitempty"`
+	Description string `yaml:"description,omitempty"`
+
+	// the explicit timestamps, override the git history
+	Date    time.Time `yaml:"date,omitempty"`
+	Updated time.Time `yaml:"updated,omitempty"`
+
+	// the draft post is only generated when the drafts is enabled
+	Draft bool `yaml:"draft,omitempty"`
+
+	// override the markdown parser extensions and the HTML flags
+	Markdown config.Markdown `yaml:"markdown,omitempty"`
+}
+
+// split the front matter and the markdown context, return the original
+// text if there is no front matter
+func SplitFrontMatter(text []byte) (meta FrontMatter, md []byte, err error) {
+	md = text
+
+	head := bytes.TrimPrefix(text, []byte("\ufeff"))
+	if !bytes.HasPrefix(head, FRONT_MATTER_DELIMITER) {
+		// no front matter
+		return
+	}
+
+	lines := bytes.SplitAfter(head, []byte("\n"))
+	if len(lines) == 0 || !bytes.Equal(bytes.TrimSpace(lines[0]), FRONT_MATTER_DELIMITER) {
+		// not the front matter delimiter, may the horizontal rule
+		return
+	}
+
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if bytes.Equal(bytes.TrimSpace(line), FRONT_MATTER_DELIMITER) {
+			if err = yaml.Unmarshal(head[len(lines[0]):offset], &meta); err != nil {
+				err = fmt.Errorf("invalid front matter: %v", err)
+				return
+			}
+
+			md = head[offset+len(line):]
+			return
+		}
+
+		offset += len(line)
+	}
+
+	// the front matter is not closed, treat as the plain markdown
+	return
+}
@@ -0,0 +1,277 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	std_html "html"
+	"strings"
+
+	"github.com/cmj0121/gitup/config"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/yuin/goldmark"
+	gm_ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	gm_parser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gm_html "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// the GitHub Flavored Markdown engine by goldmark, which is CommonMark
+// compliant and shares the extension and the flag names of gomarkdown
+type GFM struct{}
+
+// render the blog/markdown by goldmark
+func (engine GFM) Render(blog *Blog) (rendered Rendered, err error) {
+	var extensions parser.Extensions
+	var flags html.Flags
+	if extensions, flags, err = blog.markdown_options(); err != nil {
+		// invalid extension or flag
+		return
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(gfm_extensions(extensions, flags)...),
+		goldmark.WithParserOptions(gfm_parser_options(extensions)...),
+		goldmark.WithRendererOptions(gfm_renderer_options(extensions, flags)...),
+	)
+
+	doc := md.Parser().Parse(text.NewReader(blog.md))
+	anchors := flags&html.TOC != 0 || (blog.conf != nil && blog.conf.DisabledInlineTOC)
+	rendered.TOC = gfm_build_toc(doc, blog.md, anchors)
+	rendered.Words = gfm_count_words(doc, blog.md)
+	blog.gfm_decorate(doc, blog.md, flags)
+
+	var buff bytes.Buffer
+	if flags&html.TOC != 0 && len(rendered.TOC) > 0 {
+		// the inline TOC before the blog as gomarkdown
+		write_toc(&buff, rendered.TOC)
+	}
+
+	if err = md.Renderer().Render(&buff, blog.md, doc); err != nil {
+		// cannot render the HTML
+		err = fmt.Errorf("cannot render by gfm: %v", err)
+		return
+	}
+
+	rendered.HTML = buff.Bytes()
+	return
+}
+
+// the goldmark extensions by the gomarkdown extensions and flags, the task
+// list is always enabled as GitHub
+func gfm_extensions(extensions parser.Extensions, flags html.Flags) (exts []goldmark.Extender) {
+	exts = []goldmark.Extender{extension.TaskList}
+
+	for _, pair := range []struct {
+		parser.Extensions
+		goldmark.Extender
+	}{
+		{parser.Tables, extension.Table},
+		{parser.Strikethrough, extension.Strikethrough},
+		{parser.Autolink, extension.Linkify},
+		{parser.Footnotes, extension.Footnote},
+		{parser.DefinitionLists, extension.DefinitionList},
+	} {
+		if extensions&pair.Extensions != 0 {
+			exts = append(exts, pair.Extender)
+		}
+	}
+
+	if flags&html.Smartypants != 0 {
+		exts = append(exts, extension.Typographer)
+	}
+	return
+}
+
+// the goldmark parser options by the gomarkdown extensions
+func gfm_parser_options(extensions parser.Extensions) (options []gm_parser.Option) {
+	if extensions&parser.AutoHeadingIDs != 0 {
+		options = append(options, gm_parser.WithAutoHeadingID())
+	}
+	if extensions&(parser.HeadingIDs|parser.Attributes) != 0 {
+		options = append(options, gm_parser.WithHeadingAttribute())
+	}
+	if extensions&parser.Attributes != 0 {
+		options = append(options, gm_parser.WithAttribute())
+	}
+	return
+}
+
+// the goldmark renderer options by the gomarkdown extensions and flags
+func gfm_renderer_options(extensions parser.Extensions, flags html.Flags) (options []renderer.Option) {
+	if extensions&parser.HardLineBreak != 0 {
+		options = append(options, gm_html.WithHardWraps())
+	}
+	if flags&html.UseXHTML != 0 {
+		options = append(options, gm_html.WithXHTML())
+	}
+	if flags&html.SkipHTML == 0 {
+		// keep the raw HTML as gomarkdown
+		options = append(options, gm_html.WithUnsafe())
+	}
+	return
+}
+
+// set the target, the rel of the external links and lazy load the images
+// by the HTML flags
+func (blog *Blog) gfm_decorate(doc gm_ast.Node, source []byte, flags html.Flags) {
+	var rel []string
+	if flags&html.NofollowLinks != 0 {
+		rel = append(rel, "nofollow")
+	}
+	if flags&html.NoreferrerLinks != 0 {
+		rel = append(rel, "noreferrer")
+	}
+	if flags&html.NoopenerLinks != 0 {
+		rel = append(rel, "noopener")
+	}
+
+	gm_ast.Walk(doc, func(node gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) { // nolint
+		if !entering {
+			return gm_ast.WalkContinue, nil
+		}
+
+		var link string
+		switch node := node.(type) {
+		case *gm_ast.Link:
+			link = string(node.Destination)
+		case *gm_ast.AutoLink:
+			link = string(node.URL(source))
+		case *gm_ast.Image:
+			if flags&html.LazyLoadImages != 0 {
+				node.SetAttributeString("loading", []byte("lazy"))
+			}
+			return gm_ast.WalkContinue, nil
+		default:
+			return gm_ast.WalkContinue, nil
+		}
+
+		if !blog.is_external(link) {
+			// the internal link
+			return gm_ast.WalkContinue, nil
+		}
+
+		if flags&html.HrefTargetBlank != 0 {
+			node.SetAttributeString("target", []byte("_blank"))
+		}
+		if len(rel) > 0 {
+			node.SetAttributeString("rel", []byte(strings.Join(rel, " ")))
+		}
+		return gm_ast.WalkContinue, nil
+	})
+}
+
+// build the table of contents from the headings, the heading without ID has
+// the toc_N ID as gomarkdown does when the anchors are required by the TOC
+func gfm_build_toc(doc gm_ast.Node, source []byte, anchors bool) (toc []*Heading) {
+	var stack []*Heading
+
+	count := 0
+	gm_ast.Walk(doc, func(node gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) { // nolint
+		heading, ok := node.(*gm_ast.Heading)
+		if !ok || !entering {
+			return gm_ast.WalkContinue, nil
+		}
+
+		id, ok := heading.AttributeString("id")
+		if !ok && anchors {
+			id = []byte(fmt.Sprintf("toc_%d", count))
+			heading.SetAttributeString("id", id)
+		}
+		count++
+
+		item := &Heading{
+			Level: heading.Level,
+			Title: strings.TrimSpace(gfm_plain_text(heading, source)),
+		}
+		switch id := id.(type) {
+		case []byte:
+			item.ID = string(id)
+		case string:
+			item.ID = id
+		}
+
+		// the parent is the nearest heading with the lower level
+		for len(stack) > 0 && stack[len(stack)-1].Level >= item.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		switch len(stack) {
+		case 0:
+			toc = append(toc, item)
+		default:
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, item)
+
+		return gm_ast.WalkSkipChildren, nil
+	})
+
+	return
+}
+
+// count the words of the text, skip the code block
+func gfm_count_words(doc gm_ast.Node, source []byte) (words int) {
+	gm_ast.Walk(doc, func(node gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) { // nolint
+		switch node := node.(type) {
+		case *gm_ast.FencedCodeBlock, *gm_ast.CodeBlock:
+			return gm_ast.WalkSkipChildren, nil
+		case *gm_ast.Text:
+			if entering {
+				words += config.WordCount(string(node.Segment.Value(source)))
+			}
+		case *gm_ast.String:
+			if entering {
+				words += config.WordCount(string(node.Value))
+			}
+		}
+		return gm_ast.WalkContinue, nil
+	})
+
+	return
+}
+
+// the plain text of the node, like the heading title
+func gfm_plain_text(node gm_ast.Node, source []byte) (text string) {
+	var builder strings.Builder
+	gm_ast.Walk(node, func(node gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) { // nolint
+		switch node := node.(type) {
+		case *gm_ast.Text:
+			if entering {
+				builder.Write(node.Segment.Value(source))
+			}
+		case *gm_ast.String:
+			if entering {
+				builder.Write(node.Value)
+			}
+		}
+		return gm_ast.WalkContinue, nil
+	})
+
+	text = builder.String()
+	return
+}
+
+// write the inline table of contents as gomarkdown
+func write_toc(buff *bytes.Buffer, toc []*Heading) {
+	buff.WriteString("<nav>\n\n")
+	write_toc_list(buff, toc)
+	buff.WriteString("\n</nav>\n\n")
+}
+
+// write the nested list of the headings
+func write_toc_list(buff *bytes.Buffer, headings []*Heading) {
+	buff.WriteString("<ul>\n")
+	for _, heading := range headings {
+		fmt.Fprintf(buff, `<li><a href="#%v">%v</a>`, std_html.EscapeString(heading.ID), std_html.EscapeString(heading.Title))
+		if len(heading.Children) > 0 {
+			buff.WriteString("\n")
+			write_toc_list(buff, heading.Children)
+		}
+		buff.WriteString("</li>\n")
+	}
+	buff.WriteString("</ul>\n")
+}
@@ -0,0 +1,286 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the built-in shortcode to include the file into the code block, like
+	// {{< include path="main.go" lines="10-20" >}}
+	SHORTCODE_INCLUDE = "include"
+)
+
+var (
+	// the language of the code block by the extension, or the extension
+	// without the dot if not listed
+	LANGUAGES = map[string]string{
+		".c":    "c",
+		".h":    "c",
+		".cc":   "cpp",
+		".cpp":  "cpp",
+		".hpp":  "cpp",
+		".cs":   "csharp",
+		".go":   "go",
+		".htm":  "html",
+		".html": "html",
+		".js":   "javascript",
+		".kt":   "kotlin",
+		".md":   "markdown",
+		".pl":   "perl",
+		".py":   "python",
+		".rb":   "ruby",
+		".rs":   "rust",
+		".sh":   "bash",
+		".ts":   "typescript",
+		".yml":  "yaml",
+		".yaml": "yaml",
+	}
+
+	// the language of the file without the extension
+	LANGUAGE_FILES = map[string]string{
+		"Dockerfile": "dockerfile",
+		"Makefile":   "makefile",
+	}
+
+	// the marker of the named region, like // #region setup and // #endregion
+	RE_REGION_START = regexp.MustCompile(`#region\s+(\S+)`)
+	RE_REGION_END   = regexp.MustCompile(`#endregion\b`)
+)
+
+// read the included file, the path is the related path in the repository
+type Includer func(path string) (data []byte, err error)
+
+// set the reader of the included files, like the file system of the repository
+func (blog *Blog) SetIncluder(includer Includer) {
+	blog.includer = includer
+}
+
+// read the included files from the file system, and reject the path which
+// is outside the file system
+func FSIncluder(fsys fs.FS) (includer Includer) {
+	includer = func(name string) (data []byte, err error) {
+		if !fs.ValidPath(name) {
+			err = fmt.Errorf("invalid path: %v", name)
+			return
+		}
+
+		data, err = fs.ReadFile(fsys, name)
+		return
+	}
+	return
+}
+
+// read the included files from the folder, and reject the path which is
+// outside the folder or through the symlink
+func DirIncluder(root string) (includer Includer) {
+	includer = func(name string) (data []byte, err error) {
+		if !fs.ValidPath(name) {
+			err = fmt.Errorf("invalid path: %v", name)
+			return
+		}
+
+		if err = CheckSymlink(root, name); err != nil {
+			// the symlink may point outside the folder
+			return
+		}
+
+		data, err = os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		return
+	}
+	return
+}
+
+// reject the path which is the symlink, or under the symlinked folder, in the
+// root folder, the symlink may point outside the root folder
+func CheckSymlink(root, name string) (err error) {
+	current := root
+	for _, part := range strings.Split(name, "/") {
+		current = filepath.Join(current, part)
+
+		var info os.FileInfo
+		if info, err = os.Lstat(current); err != nil {
+			// not found or cannot access
+			return
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			err = fmt.Errorf("the symlink is not allowed: %v", name)
+			return
+		}
+	}
+
+	return
+}
+
+// render the included file as the code block
+func (expander *shortcode_expander) include(tag shortcode_tag, offset int) (html []byte, err error) {
+	shortcode := Shortcode{Name: tag.name, Params: tag.params, Args: tag.args}
+
+	name := shortcode.Get("path")
+	if name == "" {
+		name = shortcode.Get(0)
+	}
+
+	switch {
+	case name == "":
+		err = expander.error(offset, fmt.Errorf("shortcode include: missing the parameter path"))
+		return
+	case expander.blog.includer == nil:
+		err = expander.error(offset, fmt.Errorf("shortcode include: cannot include %v without the repository", name))
+		return
+	case strings.HasPrefix(name, "/"):
+		// the path from the repository root
+		name = path.Clean(strings.TrimLeft(name, "/"))
+	default:
+		// the path related to the blog/markdown
+		name = path.Join(path.Dir(expander.blog.Path), name)
+	}
+
+	var data []byte
+	if data, err = expander.blog.includer(name); err != nil {
+		err = expander.error(offset, fmt.Errorf("shortcode include: %v", err))
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	switch lines_range, region := shortcode.Get("lines"), shortcode.Get("region"); {
+	case lines_range != "" && region != "":
+		err = fmt.Errorf("the lines and the region cannot be used together")
+	case lines_range != "":
+		lines, err = include_lines(lines, lines_range)
+	case region != "":
+		lines, err = include_region(lines, region)
+	}
+	if err != nil {
+		err = expander.error(offset, fmt.Errorf("shortcode include %v: %v", name, err))
+		return
+	}
+
+	language := shortcode.Get("lang")
+	if language == "" {
+		language = language_of(name)
+	}
+
+	var buff bytes.Buffer
+	write_fence(&buff, language, strings.Join(dedent(lines), "\n"))
+
+	renderer := expander.renderer
+	if _, ok := renderer.(RawHTML); ok {
+		// the HTML source has no markdown engine for the code block
+		renderer = GoMarkdown{}
+	}
+
+	html, err = expander.render_markdown(renderer, buff.Bytes(), offset)
+	return
+}
+
+// the lines in the range, like 10-20, 10-, -20 or 15, the line number is
+// 1-based and inclusive
+func include_lines(lines []string, lines_range string) (included []string, err error) {
+	from, to := lines_range, lines_range
+	if idx := strings.IndexByte(lines_range, '-'); idx >= 0 {
+		from, to = lines_range[:idx], lines_range[idx+1:]
+	}
+
+	start, end := 1, len(lines)
+	if from = strings.TrimSpace(from); from != "" {
+		if start, err = strconv.Atoi(from); err != nil {
+			err = fmt.Errorf("invalid lines: %v", lines_range)
+			return
+		}
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if end, err = strconv.Atoi(to); err != nil {
+			err = fmt.Errorf("invalid lines: %v", lines_range)
+			return
+		}
+	}
+
+	if start < 1 || end > len(lines) || start > end {
+		err = fmt.Errorf("the lines %v out of the range 1-%d", lines_range, len(lines))
+		return
+	}
+
+	included = lines[start-1 : end]
+	return
+}
+
+// the lines of the named region, the markers of the nested regions are
+// removed
+func include_region(lines []string, region string) (included []string, err error) {
+	depth := 0
+	for _, line := range lines {
+		start := RE_REGION_START.FindStringSubmatch(line)
+		switch {
+		case depth == 0 && start != nil && start[1] == region:
+			depth = 1
+		case depth == 0:
+			// outside the region
+		case start != nil:
+			depth++
+		case RE_REGION_END.MatchString(line):
+			if depth--; depth == 0 {
+				// the end of the region
+				return
+			}
+		default:
+			included = append(included, line)
+		}
+	}
+
+	switch depth {
+	case 0:
+		err = fmt.Errorf("the region %v not found", region)
+	default:
+		err = fmt.Errorf("the region %v is not closed", region)
+	}
+	return
+}
+
+// remove the common leading whitespace of the lines
+func dedent(lines []string) (dedented []string) {
+	prefix, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			// the blank line
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		switch {
+		case !found:
+			prefix, found = indent, true
+		default:
+			for !strings.HasPrefix(indent, prefix) {
+				prefix = prefix[:len(prefix)-1]
+			}
+		}
+	}
+
+	for _, line := range lines {
+		dedented = append(dedented, strings.TrimPrefix(line, prefix))
+	}
+	return
+}
+
+// the language of the code block by the file name
+func language_of(name string) (language string) {
+	if language = LANGUAGE_FILES[path.Base(name)]; language != "" {
+		// the well-known file
+		return
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if language = LANGUAGES[ext]; language == "" {
+		language = strings.TrimPrefix(ext, ".")
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package blog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":        {Data: []byte("package main\n\nfunc main() {\n\t// #region setup\n\tx := 1\n\tif x > 0 {\n\t\tprintln(\"<x>\")\n\t}\n\t// #endregion\n}\n")},
+		"Dockerfile":     {Data: []byte("FROM scratch\n")},
+		"posts/snip.txt": {Data: []byte("```\nfenced\n```\n")},
+	}
+
+	conf := &config.Config{}
+	for _, engine := range []string{"gomarkdown", "gfm"} {
+		conf.Markdown.Engine = engine
+
+		for text, expect := range map[string]string{
+			`{{< include path="/main.go" lines="3-3" >}}`:   `<code class="language-go">func main() {`,
+			`{{< include "../main.go" region="setup" >}}`:   "x := 1\nif x &gt; 0 {\n\tprintln(&#34;&lt;x&gt;&#34;)\n}",
+			`{{< include /Dockerfile >}}`:                   `<code class="language-dockerfile">FROM scratch`,
+			`{{< include snip.txt lang=md >}}`:              "<code class=\"language-md\">```\nfenced\n```",
+			`{{< include /main.go lines="-1" lang=text >}}`: `<code class="language-text">package main`,
+		} {
+			blog, _ := New(strings.NewReader("# Include\n\n" + text + "\n"))
+			blog.Path = "posts/include.md"
+			blog.SetConfig(conf)
+			blog.SetIncluder(FSIncluder(fsys))
+
+			data, err := blog.RenderHTML()
+			if err != nil {
+				t.Fatalf("%v cannot render %v: %v", engine, text, err)
+			}
+
+			if html := strings.ReplaceAll(string(data), "&quot;", "&#34;"); !strings.Contains(html, expect) {
+				t.Errorf("%v expect %q in the HTML of %v:\n%v", engine, expect, text, html)
+			}
+		}
+	}
+
+	for text, expect := range map[string]string{
+		`{{< include >}}`:                                   "posts/include.md:3: shortcode include: missing the parameter path",
+		`{{< include ../../etc/passwd >}}`:                  "posts/include.md:3: shortcode include: invalid path: ../etc/passwd",
+		`{{< include /missing.go >}}`:                       "posts/include.md:3: shortcode include: open missing.go",
+		`{{< include /main.go lines="5-99" >}}`:             "posts/include.md:3: shortcode include main.go: the lines 5-99 out of the range 1-10",
+		`{{< include /main.go lines="a-b" >}}`:              "posts/include.md:3: shortcode include main.go: invalid lines: a-b",
+		`{{< include /main.go region="unknown" >}}`:         "posts/include.md:3: shortcode include main.go: the region unknown not found",
+		`{{< include /main.go lines="1" region="setup" >}}`: "posts/include.md:3: shortcode include main.go: the lines and the region cannot be used together",
+	} {
+		blog, _ := New(strings.NewReader("# Include\n\n" + text + "\n"))
+		blog.Path = "posts/include.md"
+		blog.SetConfig(conf)
+		blog.SetIncluder(FSIncluder(fsys))
+
+		_, err := blog.RenderHTML()
+		var shortcode ShortcodeError
+		if !errors.As(err, &shortcode) || !strings.HasPrefix(err.Error(), expect) {
+			t.Errorf("expect the error %v: %v", expect, err)
+		}
+	}
+
+	blog, _ := New(strings.NewReader("# Include\n\n{{< include /main.go >}}\n"))
+	if _, err := blog.RenderHTML(); err == nil || !strings.Contains(err.Error(), "without the repository") {
+		t.Errorf("expect the error without the includer: %v", err)
+	}
+
+	if _, err := include_region([]string{"// #region a", "x"}, "a"); err == nil {
+		t.Errorf("expect the error of the unclosed region")
+	}
+}
+
+func TestDirIncluder(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("the secret"), 0640)      // nolint
+	os.MkdirAll(filepath.Join(root, "posts"), 0750)                                     // nolint
+	os.WriteFile(filepath.Join(root, "posts", "main.go"), []byte("package main"), 0640) // nolint
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "posts", "leak.txt")); err != nil {
+		t.Skipf("cannot create the symlink: %v", err)
+	}
+	os.Symlink(outside, filepath.Join(root, "linked")) // nolint
+
+	includer := DirIncluder(root)
+	if data, err := includer("posts/main.go"); err != nil || string(data) != "package main" {
+		t.Errorf("expect the included file: %q %v", data, err)
+	}
+
+	for _, name := range []string{"posts/leak.txt", "linked/secret.txt", "../secret.txt"} {
+		if data, err := includer(name); err == nil {
+			t.Errorf("expect %v rejected: %q", name, data)
+		}
+	}
+}
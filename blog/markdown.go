@@ -0,0 +1,171 @@
+package blog
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+var (
+	// the markdown parser extensions by name
+	EXTENSIONS = map[string]parser.Extensions{
+		"no_intra_emphasis":          parser.NoIntraEmphasis,
+		"tables":                     parser.Tables,
+		"fenced_code":                parser.FencedCode,
+		"autolink":                   parser.Autolink,
+		"strikethrough":              parser.Strikethrough,
+		"lax_html_blocks":            parser.LaxHTMLBlocks,
+		"space_headings":             parser.SpaceHeadings,
+		"hard_line_break":            parser.HardLineBreak,
+		"non_blocking_space":         parser.NonBlockingSpace,
+		"tab_size_eight":             parser.TabSizeEight,
+		"footnotes":                  parser.Footnotes,
+		"no_empty_line_before_block": parser.NoEmptyLineBeforeBlock,
+		"heading_ids":                parser.HeadingIDs,
+		"titleblock":                 parser.Titleblock,
+		"auto_heading_ids":           parser.AutoHeadingIDs,
+		"backslash_line_break":       parser.BackslashLineBreak,
+		"definition_lists":           parser.DefinitionLists,
+		"mathjax":                    parser.MathJax,
+		"ordered_list_start":         parser.OrderedListStart,
+		"attributes":                 parser.Attributes,
+		"super_subscript":            parser.SuperSubscript,
+		"empty_lines_break_list":     parser.EmptyLinesBreakList,
+		"mmark":                      parser.Mmark,
+	}
+
+	// the HTML renderer flags by name
+	HTML_FLAGS = map[string]html.Flags{
+		"skip_html":                 html.SkipHTML,
+		"skip_images":               html.SkipImages,
+		"skip_links":                html.SkipLinks,
+		"safelink":                  html.Safelink,
+		"nofollow_links":            html.NofollowLinks,
+		"noreferrer_links":          html.NoreferrerLinks,
+		"noopener_links":            html.NoopenerLinks,
+		"href_target_blank":         html.HrefTargetBlank,
+		"use_xhtml":                 html.UseXHTML,
+		"footnote_return_links":     html.FootnoteReturnLinks,
+		"footnote_no_hr_tag":        html.FootnoteNoHRTag,
+		"smartypants":               html.Smartypants,
+		"smartypants_fractions":     html.SmartypantsFractions,
+		"smartypants_dashes":        html.SmartypantsDashes,
+		"smartypants_latex_dashes":  html.SmartypantsLatexDashes,
+		"smartypants_angled_quotes": html.SmartypantsAngledQuotes,
+		"smartypants_quotes_nbsp":   html.SmartypantsQuotesNBSP,
+		"toc":                       html.TOC,
+		"lazy_load_images":          html.LazyLoadImages,
+	}
+
+	// the default parser extensions and HTML flags
+	DEFAULT_EXTENSIONS = parser.CommonExtensions | parser.AutoHeadingIDs | parser.Titleblock |
+		parser.Footnotes | parser.SuperSubscript | parser.Mmark
+	DEFAULT_HTML_FLAGS = html.CommonFlags | html.HrefTargetBlank | html.TOC | html.LazyLoadImages |
+		html.NofollowLinks | html.NoreferrerLinks | html.NoopenerLinks
+
+	// the flags only applied to the external links
+	EXTERNAL_LINK_FLAGS = html.HrefTargetBlank | html.NofollowLinks | html.NoreferrerLinks | html.NoopenerLinks
+)
+
+// the parser extensions and the HTML flags, the default ones overridden by
+// the config and then the front matter of the blog
+func (blog *Blog) markdown_options() (extensions parser.Extensions, flags html.Flags, err error) {
+	extensions, flags = DEFAULT_EXTENSIONS, DEFAULT_HTML_FLAGS
+
+	for _, override := range blog.markdown_overrides() {
+		for name, enabled := range override.Extensions {
+			extension, ok := EXTENSIONS[name]
+			switch {
+			case !ok:
+				err = fmt.Errorf("unknown markdown extension: %v", name)
+				return
+			case enabled:
+				extensions |= extension
+			default:
+				extensions &^= extension
+			}
+		}
+
+		for name, enabled := range override.Flags {
+			flag, ok := HTML_FLAGS[name]
+			switch {
+			case !ok:
+				err = fmt.Errorf("unknown markdown HTML flag: %v", name)
+				return
+			case enabled:
+				flags |= flag
+			default:
+				flags &^= flag
+			}
+		}
+	}
+
+	if blog.conf != nil && blog.conf.DisabledInlineTOC {
+		// the TOC is rendered by the template
+		flags &^= html.TOC
+	}
+
+	return
+}
+
+// the default markdown engine by gomarkdown
+type GoMarkdown struct{}
+
+// render the blog/markdown by gomarkdown
+func (engine GoMarkdown) Render(blog *Blog) (rendered Rendered, err error) {
+	var extensions parser.Extensions
+	var flags html.Flags
+	if extensions, flags, err = blog.markdown_options(); err != nil {
+		// invalid extension or flag
+		return
+	}
+
+	doc := markdown.Parse(blog.md, parser.NewWithExtensions(extensions))
+	rendered.TOC = build_toc(doc)
+	rendered.Words = count_words(doc)
+	rendered.HTML = markdown.Render(doc, blog.new_renderer(flags))
+	return
+}
+
+// create the HTML renderer, the nofollow and the target blank are only
+// applied to the external links
+func (blog *Blog) new_renderer(flags html.Flags) (render *html.Renderer) {
+	internal := html.NewRenderer(html.RendererOptions{Flags: flags &^ EXTERNAL_LINK_FLAGS})
+
+	render = html.NewRenderer(html.RendererOptions{
+		Flags: flags,
+		RenderNodeHook: func(w io.Writer, node ast.Node, entering bool) (status ast.WalkStatus, ok bool) {
+			if link, is_link := node.(*ast.Link); is_link && !blog.is_external(string(link.Destination)) {
+				status, ok = internal.RenderNode(w, node, entering), true
+			}
+			return
+		},
+	})
+	return
+}
+
+// the link to the other site, not the site itself
+func (blog *Blog) is_external(link string) (external bool) {
+	if blog.conf != nil && blog.conf.BaseURL != "" {
+		base := strings.TrimSuffix(blog.conf.BaseURL, "/")
+		if link == base || strings.HasPrefix(link, base+"/") {
+			// the absolute link of the site itself
+			return
+		}
+	}
+
+	if strings.HasPrefix(link, "//") {
+		external = true
+		return
+	}
+
+	u, err := url.Parse(link)
+	external = err == nil && (u.Scheme == "http" || u.Scheme == "https")
+	return
+}
@@ -0,0 +1,52 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestMarkdownOptions(t *testing.T) {
+	text := "---\nmarkdown:\n  extensions:\n    titleblock: false\n---\n% not the title block\n\n" +
+		"# Title\n\n[internal](other.htm) [anchor](#title) [site](https://blog.example.com/a.htm) [external](https://example.com/) [lookalike](https://blog.example.com.evil.org/)\n"
+
+	blog, err := New(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("cannot create blog: %v", err)
+	}
+
+	conf := &config.Config{}
+	conf.BaseURL = "https://blog.example.com"
+	conf.Markdown.Flags = map[string]bool{"toc": false}
+	blog.SetConfig(conf)
+
+	data, err := blog.RenderHTML()
+	if err != nil {
+		t.Fatalf("cannot render: %v", err)
+	}
+
+	html := string(data)
+	for _, expect := range []string{
+		`<p>% not the title block</p>`,
+		`<a href="other.htm">internal</a>`,
+		`<a href="#title">anchor</a>`,
+		`<a href="https://blog.example.com/a.htm">site</a>`,
+		`<a href="https://example.com/" target="_blank" rel="nofollow noreferrer noopener">external</a>`,
+		`<a href="https://blog.example.com.evil.org/" target="_blank" rel="nofollow noreferrer noopener">lookalike</a>`,
+	} {
+		if !strings.Contains(html, expect) {
+			t.Errorf("expect %v in the HTML:\n%v", expect, html)
+		}
+	}
+	if strings.Contains(html, "<nav>") {
+		t.Errorf("expect the TOC disabled by the config:\n%v", html)
+	}
+
+	conf.Markdown.Extensions = map[string]bool{"unknown": true}
+	blog, _ = New(strings.NewReader(text))
+	blog.SetConfig(conf)
+	if _, err := blog.RenderHTML(); err == nil {
+		t.Errorf("expect the unknown extension fails")
+	}
+}
@@ -0,0 +1,148 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var (
+	// the image outputs of the notebook, in the preferred order
+	NOTEBOOK_IMAGES = []string{"image/png", "image/jpeg", "image/gif", "image/svg+xml"}
+)
+
+// the Jupyter notebook, the markdown and the code cells with the text and
+// the image outputs are converted into the blog/markdown
+type Notebook struct{}
+
+// the text of the notebook, a string or the list of lines
+type notebook_text string
+
+func (text *notebook_text) UnmarshalJSON(data []byte) (err error) {
+	var lines []string
+	if err = json.Unmarshal(data, &lines); err == nil {
+		*text = notebook_text(strings.Join(lines, ""))
+		return
+	}
+
+	var line string
+	if err = json.Unmarshal(data, &line); err != nil {
+		// neither the string nor the list of lines
+		return
+	}
+
+	*text = notebook_text(line)
+	return
+}
+
+// the nbformat 4 of the Jupyter notebook
+type notebook struct {
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+
+	Cells []struct {
+		CellType string        `json:"cell_type"`
+		Source   notebook_text `json:"source"`
+		Outputs  []struct {
+			OutputType string                   `json:"output_type"`
+			Text       notebook_text            `json:"text"`
+			Data       map[string]notebook_text `json:"data"`
+			Ename      string                   `json:"ename"`
+			Evalue     string                   `json:"evalue"`
+		} `json:"outputs"`
+	} `json:"cells"`
+}
+
+// convert the notebook into the blog/markdown
+func (format Notebook) Convert(path string, text []byte) (converted []byte, err error) {
+	var nb notebook
+	if err = json.Unmarshal(text, &nb); err != nil {
+		// invalid notebook
+		err = fmt.Errorf("invalid notebook: %v", err)
+		return
+	}
+
+	language := nb.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = nb.Metadata.Kernelspec.Language
+	}
+
+	var buff bytes.Buffer
+	for _, cell := range nb.Cells {
+		source := strings.TrimRight(string(cell.Source), "\n")
+		if source == "" && len(cell.Outputs) == 0 {
+			// the empty cell
+			continue
+		}
+
+		switch cell.CellType {
+		case "markdown", "raw":
+			buff.WriteString(source)
+			buff.WriteString("\n\n")
+		case "code":
+			if source != "" {
+				write_fence(&buff, language, source)
+			}
+
+			for _, output := range cell.Outputs {
+				switch output.OutputType {
+				case "stream":
+					write_fence(&buff, "", strings.TrimRight(string(output.Text), "\n"))
+				case "error":
+					write_fence(&buff, "", fmt.Sprintf("%v: %v", output.Ename, output.Evalue))
+				case "execute_result", "display_data":
+					write_output(&buff, output.Data)
+				}
+			}
+		}
+	}
+
+	converted = buff.Bytes()
+	return
+}
+
+// the configured markdown engine
+func (format Notebook) Engine() (engine string) {
+	return
+}
+
+// write the rich output, the image as the data URI or the plain text
+func write_output(buff *bytes.Buffer, data map[string]notebook_text) {
+	for _, mime := range NOTEBOOK_IMAGES {
+		image, ok := data[mime]
+		if !ok {
+			continue
+		}
+
+		encoded := strings.Join(strings.Fields(string(image)), "")
+		if mime == "image/svg+xml" {
+			// the SVG is the plain text in the notebook
+			encoded = base64.StdEncoding.EncodeToString([]byte(image))
+		}
+		fmt.Fprintf(buff, "![output](data:%v;base64,%v)\n\n", mime, encoded)
+		return
+	}
+
+	if text, ok := data["text/plain"]; ok {
+		write_fence(buff, "", strings.TrimRight(string(text), "\n"))
+	}
+}
+
+// write the fenced code block, the fence is longer than the backticks in
+// the code
+func write_fence(buff *bytes.Buffer, language, code string) {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	fmt.Fprintf(buff, "%v%v\n%v\n%v\n\n", fence, language, code, fence)
+}
@@ -0,0 +1,89 @@
+package blog
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/cmj0121/gitup/config"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// the raw HTML engine, the HTML fragment is kept as-is
+type RawHTML struct{}
+
+// keep the HTML as-is, and find the headings and the words
+func (engine RawHTML) Render(blog *Blog) (rendered Rendered, err error) {
+	rendered.HTML = blog.md
+	rendered.TOC, rendered.Words = outline(blog.md)
+	return
+}
+
+// the table of contents and the number of words of the HTML, skip the code
+// block, the script and the style
+func outline(text []byte) (toc []*Heading, words int) {
+	var stack []*Heading
+	var heading *Heading
+	var title strings.Builder
+
+	skipped := 0
+	tokenizer := html.NewTokenizer(bytes.NewReader(text))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// the end of the HTML
+			return
+		case html.TextToken:
+			text := string(tokenizer.Text())
+			if skipped == 0 {
+				words += config.WordCount(text)
+			}
+			if heading != nil {
+				title.WriteString(text)
+			}
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Pre, atom.Script, atom.Style:
+				skipped++
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				heading = &Heading{Level: int(token.Data[1] - '0')}
+				for _, attr := range token.Attr {
+					if attr.Key == "id" {
+						heading.ID = attr.Val
+					}
+				}
+				title.Reset()
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Pre, atom.Script, atom.Style:
+				if skipped > 0 {
+					skipped--
+				}
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				if heading == nil {
+					// the unpaired end tag
+					continue
+				}
+				heading.Title = strings.TrimSpace(title.String())
+
+				// the parent is the nearest heading with the lower level
+				for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+					stack = stack[:len(stack)-1]
+				}
+
+				switch len(stack) {
+				case 0:
+					toc = append(toc, heading)
+				default:
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, heading)
+				}
+				stack = append(stack, heading)
+				heading = nil
+			}
+		}
+	}
+}
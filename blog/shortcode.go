@@ -0,0 +1,536 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+const (
+	// the delimiters of the shortcode, like {{< figure src="a.png" >}}
+	SHORTCODE_OPEN  = "{{<"
+	SHORTCODE_CLOSE = ">}}"
+)
+
+var (
+	// the placeholder of the rendered shortcode, the one in the single
+	// paragraph is replaced with the paragraph
+	RE_SHORTCODE_PLACEHOLDER = regexp.MustCompile(`<p>GITUPSHORTCODE(\d+)X</p>|GITUPSHORTCODE(\d+)X`)
+	// the fence of the code block, the shortcode inside is kept as-is
+	RE_SHORTCODE_FENCE = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+)
+
+// the data of the shortcode template
+type Shortcode struct {
+	// the name of the shortcode
+	Name string
+	// the named parameters, like src="a.png"
+	Params map[string]string
+	// the positional parameters
+	Args []string
+	// the rendered HTML between the opening and the closing shortcode
+	Inner template.HTML
+	// the blog which uses the shortcode
+	Blog *Blog
+}
+
+// get the named parameter, or the positional one by the index
+func (shortcode Shortcode) Get(key interface{}) (value string) {
+	switch key := key.(type) {
+	case string:
+		value = shortcode.Params[key]
+	case int:
+		if key >= 0 && key < len(shortcode.Args) {
+			value = shortcode.Args[key]
+		}
+	}
+	return
+}
+
+// get the required parameter, fails the rendering if not set
+func (shortcode Shortcode) Require(key interface{}) (value string, err error) {
+	if value = shortcode.Get(key); value == "" {
+		err = fmt.Errorf("shortcode %v: missing the parameter %v", shortcode.Name, key)
+		return
+	}
+	return
+}
+
+// the invalid shortcode in the blog/markdown
+type ShortcodeError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (err ShortcodeError) Error() (text string) {
+	switch err.Path {
+	case "":
+		text = fmt.Sprintf("line %d: %v", err.Line, err.Err)
+	default:
+		text = fmt.Sprintf("%v:%d: %v", err.Path, err.Line, err.Err)
+	}
+	return
+}
+
+// the shortcode tag in the blog/markdown
+type shortcode_tag struct {
+	start, end int // the offset of the tag
+
+	name    string
+	closing bool   // the closing tag, like {{< /callout >}}
+	escaped bool   // the escaped tag, like {{</* figure */>}}
+	literal string // the literal text of the escaped tag
+	params  map[string]string
+	args    []string
+}
+
+// expand the shortcodes into the placeholders, and render them by the
+// templates of the config
+type shortcode_expander struct {
+	blog     *Blog
+	renderer Renderer
+	conf     *config.Config
+
+	html [][]byte // the rendered shortcodes by the placeholder index
+}
+
+// expand the shortcodes of the blog, return the blog with the placeholders
+// which are filled after rendered
+func (blog *Blog) expand_shortcodes(renderer Renderer) (source *Blog, expander *shortcode_expander, err error) {
+	expander = &shortcode_expander{blog: blog, renderer: renderer, conf: blog.conf}
+	if !bytes.Contains(blog.md, []byte(SHORTCODE_OPEN)) {
+		// no shortcode
+		source = blog
+		return
+	}
+
+	if expander.conf == nil {
+		// only the built-in and the site shortcodes
+		expander.conf = &config.Config{}
+	}
+
+	var md []byte
+	if md, err = expander.expand(blog.md, 0); err != nil {
+		// invalid shortcode
+		return
+	}
+
+	dup := *blog
+	dup.md = md
+	source = &dup
+	return
+}
+
+// expand the shortcodes in the markdown, the base is the offset of the text
+// in the blog/markdown
+func (expander *shortcode_expander) expand(text []byte, base int) (expanded []byte, err error) {
+	var tags []shortcode_tag
+	if tags, err = expander.parse(text, base); err != nil {
+		// invalid shortcode tag
+		return
+	}
+
+	// pair the opening and the closing tags, the unpaired opening tag is the
+	// self-closing one
+	closing := make([]int, len(tags))
+	var stack []int
+	for idx, tag := range tags {
+		closing[idx] = -1
+		switch {
+		case tag.escaped:
+			// the literal text, never paired
+		case !tag.closing:
+			stack = append(stack, idx)
+		default:
+			found := false
+			for len(stack) > 0 && !found {
+				open := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if found = tags[open].name == tag.name; found {
+					closing[open] = idx
+				}
+			}
+
+			if !found {
+				err = expander.error(base+tag.start, fmt.Errorf("unexpected closing shortcode %v", tag.name))
+				return
+			}
+		}
+	}
+
+	var buff bytes.Buffer
+	pos := 0
+	for idx := 0; idx < len(tags); idx++ {
+		tag := tags[idx]
+		buff.Write(text[pos:tag.start])
+		pos = tag.end
+
+		if tag.escaped {
+			// the literal shortcode
+			buff.WriteString(tag.literal)
+			continue
+		}
+
+		var inner template.HTML
+		if end := closing[idx]; end >= 0 {
+			if inner, err = expander.render_inner(text[tag.end:tags[end].start], base+tag.end); err != nil {
+				// invalid inner shortcode
+				return
+			}
+
+			// skip the nested tags
+			pos, idx = tags[end].end, end
+		}
+
+		var html []byte
+		if html, err = expander.render(tag, inner, base+tag.start); err != nil {
+			// cannot render the shortcode
+			return
+		}
+
+		fmt.Fprintf(&buff, "GITUPSHORTCODE%dX", len(expander.html))
+		expander.html = append(expander.html, html)
+	}
+	buff.Write(text[pos:])
+
+	expanded = buff.Bytes()
+	return
+}
+
+// render the markdown between the opening and the closing shortcodes by the
+// same engine without the inline TOC
+func (expander *shortcode_expander) render_inner(text []byte, base int) (inner template.HTML, err error) {
+	var md []byte
+	if md, err = expander.expand(text, base); err != nil {
+		// invalid nested shortcode
+		return
+	}
+
+	var html []byte
+	if html, err = expander.render_markdown(expander.renderer, md, base); err != nil {
+		// cannot render the inner markdown
+		return
+	}
+
+	inner = template.HTML(html)
+	return
+}
+
+// render the markdown snippet by the renderer without the inline TOC, the
+// nested shortcodes are filled
+func (expander *shortcode_expander) render_markdown(renderer Renderer, md []byte, base int) (html []byte, err error) {
+	dup := *expander.blog
+	dup.md = md
+	dup.Meta.Markdown.Flags = map[string]bool{"toc": false}
+	for name, enabled := range expander.blog.Meta.Markdown.Flags {
+		if name != "toc" {
+			dup.Meta.Markdown.Flags[name] = enabled
+		}
+	}
+
+	var rendered Rendered
+	if rendered, err = renderer.Render(&dup); err != nil {
+		// cannot render the markdown
+		err = expander.error(base, err)
+		return
+	}
+
+	html = bytes.TrimSpace(expander.fill(rendered.HTML))
+	return
+}
+
+// render the shortcode by the template
+func (expander *shortcode_expander) render(tag shortcode_tag, inner template.HTML, offset int) (html []byte, err error) {
+	if tag.name == SHORTCODE_INCLUDE {
+		// the built-in include shortcode
+		html, err = expander.include(tag, offset)
+		return
+	}
+
+	var tmpl *template.Template
+	if tmpl, err = expander.conf.Shortcode(tag.name); err != nil {
+		// unknown shortcode
+		err = expander.error(offset, err)
+		return
+	}
+
+	var buff bytes.Buffer
+	shortcode := Shortcode{
+		Name:   tag.name,
+		Params: tag.params,
+		Args:   tag.args,
+		Inner:  inner,
+		Blog:   expander.blog,
+	}
+	if err = tmpl.Execute(&buff, shortcode); err != nil {
+		err = expander.error(offset, err)
+		return
+	}
+
+	html = bytes.TrimSpace(buff.Bytes())
+	return
+}
+
+// replace the placeholders with the rendered shortcodes
+func (expander *shortcode_expander) fill(html []byte) (filled []byte) {
+	if len(expander.html) == 0 {
+		// no shortcode
+		filled = html
+		return
+	}
+
+	filled = RE_SHORTCODE_PLACEHOLDER.ReplaceAllFunc(html, func(matched []byte) []byte {
+		submatch := RE_SHORTCODE_PLACEHOLDER.FindSubmatch(matched)
+		idx, _ := strconv.Atoi(string(submatch[1]) + string(submatch[2]))
+		if idx >= len(expander.html) {
+			// not the placeholder of the shortcode
+			return matched
+		}
+		return expander.html[idx]
+	})
+	return
+}
+
+// the error with the source path and the line of the offset
+func (expander *shortcode_expander) error(offset int, err error) (wrapped error) {
+	if _, ok := err.(ShortcodeError); ok {
+		// already has the location
+		wrapped = err
+		return
+	}
+
+	md := expander.blog.md
+	if offset > len(md) {
+		offset = len(md)
+	}
+
+	wrapped = ShortcodeError{
+		Path: expander.blog.Path,
+		Line: expander.blog.offset + bytes.Count(md[:offset], []byte("\n")) + 1,
+		Err:  err,
+	}
+	return
+}
+
+// find the shortcode tags in the text, skip the fenced code blocks and the
+// inline code spans, only the escaped shortcode is kept in the code span
+func (expander *shortcode_expander) parse(text []byte, base int) (tags []shortcode_tag, err error) {
+	fences := fenced_ranges(text)
+	spans := code_span_ranges(text, fences)
+
+	for pos := 0; ; {
+		idx := bytes.Index(text[pos:], []byte(SHORTCODE_OPEN))
+		if idx < 0 {
+			// no more shortcode
+			return
+		}
+		start := pos + idx
+
+		if end, ok := in_ranges(fences, start); ok {
+			// inside the fenced code block
+			pos = end
+			continue
+		}
+		_, in_span := in_ranges(spans, start)
+
+		idx = bytes.Index(text[start:], []byte(SHORTCODE_CLOSE))
+		switch {
+		case idx < 0 && in_span:
+			// the literal text in the inline code span
+			pos = start + len(SHORTCODE_OPEN)
+			continue
+		case idx < 0:
+			err = expander.error(base+start, fmt.Errorf("the shortcode is not closed"))
+			return
+		}
+
+		tag := shortcode_tag{start: start, end: start + idx + len(SHORTCODE_CLOSE)}
+		body := strings.TrimSpace(string(text[start+len(SHORTCODE_OPEN) : start+idx]))
+		escaped := strings.HasPrefix(body, "/*") && strings.HasSuffix(body, "*/") && len(body) >= 4
+		if in_span && !escaped {
+			// the shortcode in the inline code span is kept as-is
+			pos = start + len(SHORTCODE_OPEN)
+			continue
+		}
+		pos = tag.end
+
+		switch {
+		case escaped:
+			tag.escaped = true
+			tag.literal = fmt.Sprintf("%v %v %v", SHORTCODE_OPEN, strings.TrimSpace(body[2:len(body)-2]), SHORTCODE_CLOSE)
+			tags = append(tags, tag)
+			continue
+		case strings.HasPrefix(body, "/"):
+			tag.closing = true
+			body = strings.TrimSpace(body[1:])
+		}
+
+		name := body
+		if idx := strings.IndexAny(body, " \t\r\n"); idx >= 0 {
+			name, body = body[:idx], body[idx:]
+		} else {
+			body = ""
+		}
+
+		if tag.name = name; !config.RE_SHORTCODE_NAME.MatchString(name) {
+			err = expander.error(base+start, fmt.Errorf("invalid shortcode name: %q", name))
+			return
+		}
+
+		if tag.params, tag.args, err = parse_shortcode_params(body); err != nil {
+			err = expander.error(base+start, fmt.Errorf("shortcode %v: %v", name, err))
+			return
+		}
+
+		tags = append(tags, tag)
+	}
+}
+
+// parse the parameters, like src="a.png" caption='the caption' 42
+func parse_shortcode_params(text string) (params map[string]string, args []string, err error) {
+	params = map[string]string{}
+
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		var key, value string
+		if idx := strings.IndexAny(text, "= \t\r\n\"'"); idx > 0 && text[idx] == '=' {
+			key, text = text[:idx], text[idx+1:]
+		}
+
+		switch {
+		case text == "":
+			// the empty value, like key=
+		case text[0] == '"' || text[0] == '\'':
+			idx := strings.IndexByte(text[1:], text[0])
+			if idx < 0 {
+				err = fmt.Errorf("the quote is not closed: %v", text)
+				return
+			}
+			value, text = text[1:idx+1], text[idx+2:]
+		default:
+			idx := strings.IndexAny(text, " \t\r\n")
+			if idx < 0 {
+				idx = len(text)
+			}
+			value, text = text[:idx], text[idx:]
+		}
+
+		switch key {
+		case "":
+			args = append(args, value)
+		default:
+			params[key] = value
+		}
+	}
+
+	return
+}
+
+// the offset ranges of the fenced code blocks
+func fenced_ranges(text []byte) (ranges [][2]int) {
+	var fence []byte
+	start := 0
+
+	for offset := 0; offset < len(text); {
+		end := bytes.IndexByte(text[offset:], '\n')
+		switch end {
+		case -1:
+			end = len(text)
+		default:
+			end += offset + 1
+		}
+		line := text[offset:end]
+
+		switch matched := RE_SHORTCODE_FENCE.FindSubmatch(line); {
+		case fence == nil && matched != nil:
+			fence, start = matched[1], offset
+		case fence != nil && matched != nil && matched[1][0] == fence[0] && len(matched[1]) >= len(fence) &&
+			len(bytes.TrimSpace(line)) == len(matched[1]):
+			ranges = append(ranges, [2]int{start, end})
+			fence = nil
+		}
+
+		offset = end
+	}
+
+	if fence != nil {
+		// the unclosed fence lasts to the end
+		ranges = append(ranges, [2]int{start, len(text)})
+	}
+	return
+}
+
+// the ranges of the inline code spans outside the fenced code blocks, like
+// `{{< youtube id >}}`, the span is closed by the backticks of the same length
+// in the same paragraph
+func code_span_ranges(text []byte, fences [][2]int) (ranges [][2]int) {
+	run := func(offset int) (size int) {
+		for offset+size < len(text) && text[offset+size] == '`' {
+			size++
+		}
+		return
+	}
+
+	for pos := 0; pos < len(text); {
+		if end, ok := in_ranges(fences, pos); ok {
+			// the backticks in the fenced code block
+			pos = end
+			continue
+		}
+
+		switch text[pos] {
+		case '\\':
+			// the escaped character, like the \`
+			pos += 2
+			continue
+		case '`':
+		default:
+			pos++
+			continue
+		}
+
+		size := run(pos)
+		closing := -1
+		for offset := pos + size; offset < len(text); {
+			idx := bytes.IndexByte(text[offset:], '`')
+			if idx < 0 || bytes.Contains(text[offset:offset+idx], []byte("\n\n")) {
+				// not closed in the same paragraph
+				break
+			}
+
+			offset += idx
+			if run(offset) == size {
+				closing = offset
+				break
+			}
+			offset += run(offset)
+		}
+
+		switch closing {
+		case -1:
+			// the literal backticks
+			pos += size
+		default:
+			ranges = append(ranges, [2]int{pos, closing + size})
+			pos = closing + size
+		}
+	}
+
+	return
+}
+
+// the offset is in one of the ranges, and return the end of the range
+func in_ranges(ranges [][2]int, offset int) (end int, ok bool) {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			end, ok = r[1], true
+			return
+		}
+	}
+	return
+}
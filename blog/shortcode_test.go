@@ -0,0 +1,76 @@
+package blog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestShortcode(t *testing.T) {
+	text := "---\ntitle: Shortcodes\n---\n# Shortcodes\n\n" +
+		"{{< figure src=\"a.png\" caption='the <caption>' >}}\n\n" +
+		"{{< callout type=warning >}}\nthe **inner** {{< badge new >}}\n{{< /callout >}}\n\n" +
+		"```\n{{< unknown >}}\n```\n\n" +
+		"`{{</* figure src=x */>}}`\n\n" +
+		"use `{{< youtube id >}}` or ``{{< figure src=`x` >}}`` and \\`{{< badge escaped >}}\\`\n"
+
+	conf := &config.Config{}
+	conf.SetSource(fstest.MapFS{
+		"layouts/shortcodes/badge.htm": {Data: []byte(`<span class="badge">{{ .Get 0 }}</span>`)},
+	})
+
+	for _, engine := range []string{"gomarkdown", "gfm"} {
+		conf.Markdown.Engine = engine
+
+		blog, err := New(strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("cannot create blog: %v", err)
+		}
+		blog.SetConfig(conf)
+
+		data, err := blog.RenderHTML()
+		if err != nil {
+			t.Fatalf("%v cannot render: %v", engine, err)
+		}
+
+		html := string(data)
+		for _, expect := range []string{
+			`<img src="a.png" alt="the &lt;caption&gt;" loading="lazy">`,
+			`<figcaption>the &lt;caption&gt;</figcaption>`,
+			`<aside class="callout callout-warning">`,
+			`<p>the <strong>inner</strong> <span class="badge">new</span></p>`,
+			`{{&lt; unknown &gt;}}`,
+			`<code>{{&lt; figure src=x &gt;}}</code>`,
+			`<code>{{&lt; youtube id &gt;}}</code>`,
+			"<code>{{&lt; figure src=`x` &gt;}}</code>",
+			"`<span class=\"badge\">escaped</span>`",
+		} {
+			if !strings.Contains(html, expect) {
+				t.Errorf("%v expect %v in the HTML:\n%v", engine, expect, html)
+			}
+		}
+		if strings.Contains(html, "<p><figure>") || strings.Contains(html, "GITUPSHORTCODE") {
+			t.Errorf("%v expect the placeholders replaced:\n%v", engine, html)
+		}
+	}
+
+	for text, expect := range map[string]string{
+		"---\ntitle: x\n---\n\n{{< unknown >}}\n": "line 5: unknown shortcode \"unknown\"",
+		"# Title\n\n{{< figure >}}\n":             "line 3: template: figure",
+		"# Title\n\n{{< /callout >}}\n":           "line 3: unexpected closing shortcode callout",
+		"# Title\n\n{{< figure src=\"a.png >}}\n": "line 3: shortcode figure: the quote is not closed",
+		"# Title\n\n{{< figure src=a.png\n":       "line 3: the shortcode is not closed",
+	} {
+		blog, _ := New(strings.NewReader(text))
+		blog.SetConfig(conf)
+
+		_, err := blog.RenderHTML()
+		var shortcode ShortcodeError
+		if !errors.As(err, &shortcode) || !strings.HasPrefix(err.Error(), expect) {
+			t.Errorf("expect the error %v: %v", expect, err)
+		}
+	}
+}
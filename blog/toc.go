@@ -0,0 +1,121 @@
+package blog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cmj0121/gitup/config"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const (
+	// the average reading speed, words per minute
+	WORDS_PER_MINUTE = 200
+)
+
+// the heading in the table of contents, nested by the level
+type Heading struct {
+	Level int
+	ID    string
+	Title string
+
+	Children []*Heading
+}
+
+// the structured table of contents, the top-level headings
+func (blog Blog) TOC() (toc []*Heading) {
+	toc = blog.toc
+	return
+}
+
+// the number of words in the blog/markdown, each CJK character is one word
+func (blog Blog) WordCount() (words int) {
+	words = blog.words
+	return
+}
+
+// the estimated reading time in minutes, at least one minute
+func (blog Blog) ReadingTime() (minutes int) {
+	minutes = (blog.words + WORDS_PER_MINUTE - 1) / WORDS_PER_MINUTE
+	if minutes < 1 {
+		minutes = 1
+	}
+	return
+}
+
+// build the table of contents from the headings, the heading without ID has
+// the toc_N ID as the inline TOC does
+func build_toc(doc ast.Node) (toc []*Heading) {
+	var stack []*Heading
+
+	count := 0
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.IsTitleblock {
+			return ast.GoToNext
+		}
+
+		if heading.HeadingID == "" {
+			heading.HeadingID = fmt.Sprintf("toc_%d", count)
+		}
+		count++
+
+		item := &Heading{
+			Level: heading.Level,
+			ID:    heading.HeadingID,
+			Title: strings.TrimSpace(plain_text(heading)),
+		}
+
+		// the parent is the nearest heading with the lower level
+		for len(stack) > 0 && stack[len(stack)-1].Level >= item.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		switch len(stack) {
+		case 0:
+			toc = append(toc, item)
+		default:
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, item)
+
+		return ast.SkipChildren
+	})
+
+	return
+}
+
+// count the words of the text, skip the fenced code block
+func count_words(doc ast.Node) (words int) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.CodeBlock:
+			return ast.SkipChildren
+		case *ast.Text, *ast.Code:
+			if entering {
+				words += config.WordCount(string(node.AsLeaf().Literal))
+			}
+		}
+		return ast.GoToNext
+	})
+
+	return
+}
+
+// the plain text of the node, like the heading title
+func plain_text(node ast.Node) (text string) {
+	var builder strings.Builder
+	ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.Text, *ast.Code:
+			if entering {
+				builder.Write(node.AsLeaf().Literal)
+			}
+		}
+		return ast.GoToNext
+	})
+
+	text = builder.String()
+	return
+}
@@ -0,0 +1,54 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestTOC(t *testing.T) {
+	text := "# Title\n> the description\n\n## First\n\n### First `code`\n\n## Second\n\nthe word's count, 中文\n\n```\nthe code block is skipped\n```\n"
+
+	blog, err := New(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("cannot create blog: %v", err)
+	}
+
+	conf := &config.Config{}
+	conf.DisabledInlineTOC = true
+	blog.SetConfig(conf)
+
+	html, _ := blog.RenderHTML()
+	if strings.Contains(string(html), "<nav>") {
+		t.Errorf("expect no inline TOC: %s", html)
+	}
+
+	toc := blog.TOC()
+	if len(toc) != 1 || toc[0].ID != "title" || len(toc[0].Children) != 2 {
+		t.Fatalf("unexpected TOC: %+v", toc)
+	}
+
+	first := toc[0].Children[0]
+	if first.Title != "First" || len(first.Children) != 1 || first.Children[0].Title != "First code" {
+		t.Errorf("unexpected nested heading: %+v", first)
+	}
+
+	// title, the description, the headings and the words, the CJK character
+	// is one word
+	if words := blog.WordCount(); words != 1+2+1+2+1+3+2 {
+		t.Errorf("unexpected word count: %v", words)
+	}
+	if minutes := blog.ReadingTime(); minutes != 1 {
+		t.Errorf("unexpected reading time: %v", minutes)
+	}
+
+	// the same count of the wordcount template function
+	blog, _ = New(strings.NewReader("# Title\n\nthe word's count, 中文 -- and *more* words\n"))
+	blog.SetConfig(conf)
+	html, _ = blog.RenderHTML()
+	wordcount := conf.FuncMap()["wordcount"].(func(interface{}) int)
+	if words := blog.WordCount(); words != wordcount(string(html)) {
+		t.Errorf("expect the word count %v of the template function: %v", wordcount(string(html)), words)
+	}
+}
@@ -0,0 +1,523 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmj0121/gitup/clone"
+	"github.com/cmj0121/gitup/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// the anchor targets in the HTML page
+	RE_ANCHOR_ID = regexp.MustCompile(`(?i)\s(?:id|name)\s*=\s*("[^"]*"|'[^']*')`)
+	// the stylesheet in the HTML page, the style element and the attribute
+	RE_STYLE_ELEMENT = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style>`)
+	RE_STYLE_ATTR    = regexp.MustCompile(`(?i)\sstyle\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// check the internal and external links of the generated webpage
+type Links struct {
+	// the folder of the generated webpage
+	Output string `arg:"" optional:"" default:"build" type:"existingdir" help:"the folder of the generated webpage"`
+
+	// the repository folder, to report the blog/markdown source and line
+	Source string `default:"." type:"existingdir" help:"the folder of the repository, used to report the source line"`
+	// the manifest of the generated pages to the sources, written by the clone
+	Manifest string `type:"path" help:"the manifest written by the clone --manifest, used to report the source line"`
+
+	// the optional external links check
+	External    bool          `help:"also check the external links"`
+	Concurrency int           `default:"8" help:"the maximum concurrent requests of the external links"`
+	Timeout     time.Duration `default:"10s" help:"the timeout of the single external link"`
+	Cache       string        `type:"path" help:"the cache file of the passed external links"`
+	CacheTTL    time.Duration `name:"cache-ttl" default:"24h" help:"the lifetime of the cached external link"`
+
+	pages   map[string]map[string]struct{} // the anchors of the generated pages
+	sources map[string]clone.ManifestPage  // the generated page to the blog/markdown source
+	cache   map[string]time.Time           // the passed external links and the checked time
+	client  *http.Client
+}
+
+// the broken link found in the generated page
+type Broken struct {
+	// the generated page and the link in the page
+	Page string
+	Link string
+
+	// the blog/markdown source and line, the line is zero if not found
+	Source string
+	Line   int
+
+	// the reason of the broken link
+	Reason string
+}
+
+// show as source:line, or the generated page if the source is unknown
+func (broken Broken) String() (str string) {
+	switch {
+	case broken.Line > 0:
+		str = fmt.Sprintf("%v:%d: %v (%v): %v", broken.Source, broken.Line, broken.Link, broken.Page, broken.Reason)
+	default:
+		str = fmt.Sprintf("%v: %v: %v", broken.Page, broken.Link, broken.Reason)
+	}
+	return
+}
+
+// check the links and exit with non-zero if any broken
+func (links *Links) Run(conf *config.Config) (err error) {
+	if err = conf.LoadFS(os.DirFS(filepath.Clean(links.Source)), "."); err != nil {
+		// invalid config in the repository
+		return
+	}
+
+	var broken []Broken
+	if broken, err = links.Check(conf); err != nil {
+		// cannot check the links
+		return
+	}
+
+	for _, item := range broken {
+		fmt.Println(item)
+	}
+
+	if len(broken) > 0 {
+		err = fmt.Errorf("%d broken link(s) found in %v", len(broken), links.Output)
+		return
+	}
+
+	fmt.Println("links OK")
+	return
+}
+
+// check all the links of the generated pages
+func (links *Links) Check(conf *config.Config) (broken []Broken, err error) {
+	output := filepath.Clean(links.Output)
+
+	var pages, sheets []string
+	err = filepath.WalkDir(output, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, _ := filepath.Rel(output, path) // nolint
+		switch {
+		case is_page(path):
+			pages = append(pages, filepath.ToSlash(rel))
+		case strings.ToLower(filepath.Ext(path)) == ".css":
+			sheets = append(sheets, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		// cannot list the generated pages
+		return
+	}
+	sort.Strings(pages)
+	sort.Strings(sheets)
+
+	links.load_sources()
+	links.load_cache()
+
+	// collect all the anchors first, the link may refer to the later page
+	texts := map[string]string{}
+	links.pages = map[string]map[string]struct{}{}
+	for _, page := range pages {
+		var data []byte
+		if data, err = os.ReadFile(filepath.Join(output, filepath.FromSlash(page))); err != nil {
+			// cannot read the generated page
+			return
+		}
+
+		texts[page] = string(data)
+		links.pages[page] = anchors(texts[page])
+	}
+
+	refs := map[string][]string{}
+	for _, page := range pages {
+		refs[page] = references(texts[page])
+	}
+	for _, sheet := range sheets {
+		var data []byte
+		if data, err = os.ReadFile(filepath.Join(output, filepath.FromSlash(sheet))); err != nil {
+			// cannot read the generated stylesheet
+			return
+		}
+
+		refs[sheet] = style_references(string(data))
+	}
+
+	var externals []Broken
+	for _, page := range append(pages, sheets...) {
+		for _, link := range refs[page] {
+			target, external := links.target(conf, page, link)
+			switch {
+			case target == "":
+				// not the checkable link, like mailto:
+			case external:
+				externals = append(externals, Broken{Page: page, Link: target})
+			default:
+				if reason := links.check_internal(output, target); reason != "" {
+					broken = append(broken, links.locate(Broken{Page: page, Link: link, Reason: reason}))
+				}
+			}
+		}
+	}
+
+	if links.External {
+		for _, item := range links.check_externals(externals) {
+			broken = append(broken, links.locate(item))
+		}
+		links.save_cache()
+	}
+
+	return
+}
+
+// resolve the link to the path in the output with the optional fragment, or
+// the external URL
+func (links *Links) target(conf *config.Config, page, link string) (target string, external bool) {
+	switch {
+	case link == "":
+		return
+	case conf.BaseURL != "" && strings.HasPrefix(link, strings.TrimSuffix(conf.BaseURL, "/")+"/"):
+		// the absolute URL of the site itself
+		link = "/" + strings.TrimPrefix(link, strings.TrimSuffix(conf.BaseURL, "/")+"/")
+	case strings.HasPrefix(link, "//"):
+		target, external = "https:"+link, true
+		return
+	}
+
+	u, err := url.Parse(link)
+	switch {
+	case err != nil:
+		target = link
+		return
+	case u.Scheme == "http" || u.Scheme == "https":
+		target, external = link, true
+		return
+	case u.Scheme != "" || u.Host != "":
+		// the mailto:, tel:, data: and others
+		return
+	}
+
+	switch {
+	case u.Path == "":
+		target = page
+	case strings.HasPrefix(u.Path, "/"):
+		target = strings.TrimPrefix(path.Clean(u.Path), "/")
+	default:
+		target = path.Join(path.Dir(page), u.Path)
+	}
+
+	if u.Fragment != "" {
+		target += "#" + u.Fragment
+	}
+	return
+}
+
+// check the internal target exists, and the anchor if has the fragment
+func (links *Links) check_internal(output, target string) (reason string) {
+	name, fragment := target, ""
+	if idx := strings.Index(target, "#"); idx >= 0 {
+		name, fragment = target[:idx], target[idx+1:]
+	}
+
+	if name == ".." || strings.HasPrefix(name, "../") {
+		reason = "outside of the site"
+		return
+	}
+
+	info, err := os.Stat(filepath.Join(output, filepath.FromSlash(name)))
+	switch {
+	case err != nil:
+		reason = "not found"
+		return
+	case info.IsDir():
+		// served as the index page of the folder
+		name = path.Join(name, "index.htm")
+		if _, ok := links.pages[name]; !ok {
+			reason = "no index page in the folder"
+			return
+		}
+	}
+
+	if fragment == "" {
+		// no anchor to check
+		return
+	}
+
+	anchors, ok := links.pages[name]
+	if !ok {
+		// the anchor of the non-page asset cannot be checked
+		return
+	}
+
+	if _, ok := anchors[fragment]; !ok {
+		reason = fmt.Sprintf("anchor #%v not found", fragment)
+	}
+	return
+}
+
+// check the external links concurrently, each URL is requested once
+func (links *Links) check_externals(externals []Broken) (broken []Broken) {
+	links.client = &http.Client{Timeout: links.Timeout}
+
+	var urls []string
+	seen := map[string]struct{}{}
+	for _, item := range externals {
+		if _, ok := seen[item.Link]; !ok {
+			seen[item.Link] = struct{}{}
+			urls = append(urls, item.Link)
+		}
+	}
+
+	var mu sync.Mutex
+	reasons := map[string]string{}
+
+	concurrency := links.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for idx := 0; idx < concurrency; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				reason := links.check_external(link)
+
+				mu.Lock()
+				reasons[link] = reason
+				if reason == "" {
+					links.cache[link] = time.Now()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, link := range urls {
+		mu.Lock()
+		checked, ok := links.cache[link]
+		mu.Unlock()
+
+		if ok && time.Since(checked) < links.CacheTTL {
+			// passed recently
+			continue
+		}
+		queue <- link
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, item := range externals {
+		if reason := reasons[item.Link]; reason != "" {
+			item.Reason = reason
+			broken = append(broken, item)
+		}
+	}
+	return
+}
+
+// request the external link, fallback to GET if the HEAD is not allowed
+func (links *Links) check_external(link string) (reason string) {
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, link, nil)
+		if err != nil {
+			reason = err.Error()
+			return
+		}
+		req.Header.Set("User-Agent", "gitup link checker")
+
+		resp, err := links.client.Do(req)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"link":  link,
+				"error": err,
+			}).Debug("cannot request the external link")
+
+			reason = err.Error()
+			return
+		}
+		resp.Body.Close()
+
+		if status = resp.StatusCode; status < 400 {
+			// the link is alive
+			return
+		}
+	}
+
+	reason = fmt.Sprintf("HTTP %d", status)
+	return
+}
+
+// find the blog/markdown source and the line of the link
+func (links *Links) locate(broken Broken) (located Broken) {
+	located = broken
+
+	page, ok := links.sources[broken.Page]
+	if !ok {
+		// not generated from the blog/markdown
+		return
+	}
+	located.Source = page.Source
+
+	file, err := os.Open(filepath.Join(filepath.Clean(links.Source), filepath.FromSlash(page.Source)))
+	if err != nil {
+		// the source is not in the repository folder
+		return
+	}
+	defer file.Close()
+
+	link := broken.Link
+	if original, ok := page.Links[link]; ok {
+		// the link resolved from the other blog/markdown source, like foo.md
+		link = original
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if bytes.Contains(scanner.Bytes(), []byte(link)) {
+			located.Line = line
+			break
+		}
+	}
+	return
+}
+
+// load the manifest of the page sources, written by the clone --manifest
+func (links *Links) load_sources() {
+	links.sources = map[string]clone.ManifestPage{}
+	if links.Manifest == "" {
+		// no manifest, report the page only
+		return
+	}
+
+	data, err := os.ReadFile(links.Manifest)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  links.Manifest,
+			"error": err,
+		}).Warn("cannot read the sources manifest")
+		return
+	}
+
+	if err := json.Unmarshal(data, &links.sources); err != nil {
+		log.WithFields(log.Fields{
+			"path":  links.Manifest,
+			"error": err,
+		}).Warn("invalid sources manifest")
+	}
+}
+
+// load the cache of the passed external links
+func (links *Links) load_cache() {
+	links.cache = map[string]time.Time{}
+	if links.Cache == "" {
+		// no persistent cache
+		return
+	}
+
+	data, err := os.ReadFile(links.Cache)
+	if err != nil {
+		// no cache yet
+		return
+	}
+
+	if err := json.Unmarshal(data, &links.cache); err != nil {
+		log.WithFields(log.Fields{
+			"path":  links.Cache,
+			"error": err,
+		}).Info("ignore the invalid link cache")
+		links.cache = map[string]time.Time{}
+	}
+}
+
+// save the cache of the passed external links
+func (links *Links) save_cache() {
+	if links.Cache == "" {
+		// no persistent cache
+		return
+	}
+
+	data, err := json.MarshalIndent(links.cache, "", "  ")
+	if err == nil {
+		err = os.WriteFile(links.Cache, data, 0640)
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  links.Cache,
+			"error": err,
+		}).Warn("cannot save the link cache")
+	}
+}
+
+// all the anchors in the HTML page
+func anchors(text string) (ids map[string]struct{}) {
+	ids = map[string]struct{}{}
+	for _, matched := range RE_ANCHOR_ID.FindAllStringSubmatch(text, -1) {
+		ids[html.UnescapeString(matched[1][1:len(matched[1])-1])] = struct{}{}
+	}
+	return
+}
+
+// all the references in the HTML page, like the href and src
+func references(text string) (refs []string) {
+	for _, matched := range clone.RE_REF_ATTR.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, html.UnescapeString(strings.TrimSpace(matched[2][1:len(matched[2])-1])))
+	}
+
+	for _, matched := range clone.RE_REF_SRCSET.FindAllStringSubmatch(text, -1) {
+		for _, candidate := range strings.Split(matched[2][1:len(matched[2])-1], ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				refs = append(refs, html.UnescapeString(fields[0]))
+			}
+		}
+	}
+
+	for _, matched := range RE_STYLE_ELEMENT.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, style_references(matched[1])...)
+	}
+	for _, matched := range RE_STYLE_ATTR.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, style_references(html.UnescapeString(matched[1][1:len(matched[1])-1]))...)
+	}
+	return
+}
+
+// all the references in the stylesheet, like the url() of the image and font
+func style_references(text string) (refs []string) {
+	for _, matched := range clone.RE_REF_URL.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, strings.Trim(matched[2], `"'`))
+	}
+	return
+}
+
+// the generated HTML page
+func is_page(file string) (page bool) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".htm", ".html":
+		page = true
+	}
+	return
+}
@@ -0,0 +1,103 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestLinks(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/head-not-allowed":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source, output := t.TempDir(), t.TempDir()
+	manifest := filepath.Join(t.TempDir(), "sources.json")
+	files := map[string]string{
+		filepath.Join(source, "posts/a.md"): "# Post A\n\nsee [B](b.htm#intro)\n\nand [old](old-post.md)\n\nand [gone](b.md#gone)\n",
+		manifest:                            `{"a.htm": {"source": "posts/a.md", "links": {"b.htm#gone": "b.md#gone"}}}`,
+		filepath.Join(output, "a.htm"): `<h1 id="post-a">Post A</h1>
+<a href="b.htm#intro">B</a> <a href="old-post.md">old</a> <a href="#post-a">top</a> <a href="#missing">missing</a>
+<a href="b.htm#gone">gone</a> <div style="background: url(&quot;img/bg.png&quot;)"></div>
+<style>body { background: url(img/logo.png); } .x { background: url('img/none.png'); }</style>
+<img src="img/logo.png" /> <a href="mailto:me@example.com">mail</a>
+<a href="` + server.URL + `/ok">ok</a> <a href="` + server.URL + `/head-not-allowed">get</a> <a href="` + server.URL + `/gone">gone</a>`,
+		filepath.Join(output, "b.htm"):        `<h2 id="intro">Intro</h2><a href="a.htm?v=1">A</a> <a href="../outside.htm">out</a> <a href="` + server.URL + `/ok">ok</a>`,
+		filepath.Join(output, "img/logo.png"): "the PNG image",
+		filepath.Join(output, "css/site.css"): `@font-face { src: url("../fonts/none.woff2") format("woff2"); } .a { background: url(data:image/png;base64,AAAA), url(../img/logo.png); }`,
+	}
+	for path, text := range files {
+		os.MkdirAll(filepath.Dir(path), 0750)  // nolint
+		os.WriteFile(path, []byte(text), 0640) // nolint
+	}
+
+	links := &Links{
+		Output:      output,
+		Source:      source,
+		Manifest:    manifest,
+		External:    true,
+		Concurrency: 2,
+		Timeout:     time.Second,
+		Cache:       filepath.Join(t.TempDir(), "cache.json"),
+		CacheTTL:    time.Hour,
+	}
+	broken, err := links.Check(&config.Config{})
+	if err != nil {
+		t.Fatalf("cannot check the links: %v", err)
+	}
+
+	var found []string
+	for _, item := range broken {
+		found = append(found, item.String())
+	}
+	sort.Strings(found)
+
+	expect := []string{
+		"a.htm: #missing: anchor #missing not found",
+		"b.htm: ../outside.htm: outside of the site",
+		"posts/a.md:5: old-post.md (a.htm): not found",
+		"posts/a.md:7: b.htm#gone (a.htm): anchor #gone not found",
+		"a.htm: img/bg.png: not found",
+		"a.htm: img/none.png: not found",
+		"css/site.css: ../fonts/none.woff2: not found",
+		"a.htm: " + server.URL + "/gone: HTTP 404",
+	}
+	sort.Strings(expect)
+
+	if len(found) != len(expect) {
+		t.Fatalf("expect %v broken links: %v", len(expect), found)
+	}
+	for idx := range expect {
+		if found[idx] != expect[idx] {
+			t.Errorf("expect %v: %v", expect[idx], found[idx])
+		}
+	}
+
+	// the passed external links are cached
+	count := atomic.LoadInt32(&requests)
+	if _, err := links.Check(&config.Config{}); err != nil {
+		t.Fatalf("cannot check the links: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != count+2 {
+		t.Errorf("expect only the broken link requested again: %v", atomic.LoadInt32(&requests)-count)
+	}
+}
@@ -0,0 +1,174 @@
+package clone
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+	"github.com/go-git/go-git/v5"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// aggregate several repositories into one site
+type Aggregate struct {
+	// the extra remote repositories, appended to the sources in config
+	Repos []*url.URL `arg:"" optional:"" help:"the remote repositories, appended to the sources in config"`
+
+	// the final destinate folder of the webpage
+	Output string `short:"o" type:"path" default:"build" help:"the destinate folder of the generated webpage"`
+
+	// the options of cloning the repository
+	Options `embed:""`
+}
+
+// clone all the source repositories and generate one site
+func (aggregate *Aggregate) Run(conf *config.Config) (err error) {
+	// the site config and the referenced files are in the current folder
+	site := &Clone{
+		Output:  aggregate.Output,
+		Options: aggregate.Options,
+		fsys:    os.DirFS("."),
+	}
+	if err = conf.LoadFS(site.fsys, "."); err != nil {
+		// invalid site config
+		return
+	}
+
+	if err = conf.CheckProfile(); err != nil {
+		// the profile is not defined
+		return
+	}
+
+	if err = conf.LoadData(); err != nil {
+		// invalid data files
+		return
+	}
+
+	sources := append([]config.Source{}, conf.Sources...)
+	for _, repo := range aggregate.Repos {
+		sources = append(sources, config.Source{Repo: repo.String()})
+	}
+
+	if len(sources) == 0 {
+		err = fmt.Errorf("no source repository")
+		return
+	}
+
+	for idx, source := range sources {
+		var blogs blog.Blogs
+		if blogs, err = aggregate.collect(conf, idx, source); err != nil {
+			log.WithFields(log.Fields{
+				"repository": redact_repository(source.Repo),
+				"error":      err,
+			}).Warn("cannot aggregate the repository")
+			return
+		}
+
+		site.blogs = append(site.blogs, blogs...)
+	}
+
+	err = site.Generate(conf)
+	return
+}
+
+// clone the source repository and collect the blogs
+func (aggregate *Aggregate) collect(conf *config.Config, idx int, source config.Source) (blogs blog.Blogs, err error) {
+	clone := &Clone{
+		Output:  aggregate.Output,
+		Options: aggregate.Options,
+	}
+	defer clone.purge()
+
+	if clone.Repo, err = url.Parse(source.Repo); err != nil {
+		// invalid repository URI, the raw URI may contain the credential
+		err = fmt.Errorf("invalid repository URI of the source #%d", idx)
+		return
+	}
+	if source.Branch != "" {
+		clone.Branch = source.Branch
+	}
+	clone.tempdir = filepath.Clean(fmt.Sprintf("%v/gitup.%d.%d", os.TempDir(), os.Getpid(), idx))
+
+	var repo *git.Repository
+	if repo, err = clone.Open(conf); err != nil {
+		// cannot open the repository
+		return
+	}
+
+	// the config of the source repository, only used to find the blogs
+	local := *conf
+	local.Author = ""
+	local.Workdir = nil
+	local.Hidden = nil
+	local.SetSource(clone.fsys)
+	if err = local.LoadFS(clone.fsys, "."); err != nil {
+		// invalid config in the source repository
+		return
+	}
+
+	if len(source.Workdir) > 0 {
+		local.Workdir = source.Workdir
+	}
+
+	for _, dir := range local.Workdir {
+		if err = clone.Process(&local, dir); err != nil {
+			log.WithFields(log.Fields{
+				"repository": clone.repository(),
+				"path":       dir,
+				"error":      err,
+			}).Error("generate blog fail")
+		}
+	}
+
+	if err = clone.Timestamp(repo); err != nil {
+		// cannot find the blogs timestamp
+		return
+	}
+
+	author := source.Author
+	if author == "" {
+		author = local.Author
+	}
+
+	for _, blog := range clone.blogs {
+		hidden := local.IsHidden(blog.Path)
+
+		// the path is unique in the site with the prefix
+		blog.Path = path.Join(source.Prefix, blog.Path)
+		blog.Prefix = source.Prefix
+		blog.Author = author
+
+		// the blog is rendered, and never includes from the purged repository
+		blog.SetIncluder(nil)
+
+		if hidden {
+			conf.Hidden = append(conf.Hidden, blog.Path)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"repository": clone.repository(),
+		"count":      len(clone.blogs),
+	}).Info("aggregate the repository")
+
+	blogs = clone.blogs
+	return
+}
+
+// the repository URI without the password, used in the logs
+func redact_repository(repo string) (redacted string) {
+	uri, err := url.Parse(repo)
+	if err != nil {
+		// the raw URI may contain the credential
+		redacted = "<invalid URI>"
+		return
+	}
+
+	redacted = uri.Redacted()
+	return
+}
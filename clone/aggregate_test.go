@@ -0,0 +1,68 @@
+package clone
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestAggregateInclude(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("cannot init repository: %v", err)
+	}
+
+	files := map[string]string{
+		".gitup.yml":     "workdir: [posts]\n",
+		"posts/post.md":  "# The Post\n\n{{< include snip.go >}}\n",
+		"posts/snip.go":  "package snip\n",
+		"posts/other.md": "# The Other\n\nsee [post](post.md)\n",
+	}
+	for name, text := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750)  // nolint
+		os.WriteFile(filepath.Join(dir, name), []byte(text), 0640) // nolint
+	}
+
+	wt, _ := repo.Worktree()
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatalf("cannot add files: %v", err)
+	}
+	signature := &object.Signature{Name: "gitup", When: time.Now()}
+	if _, err := wt.Commit("init", &git.CommitOptions{Author: signature}); err != nil {
+		t.Fatalf("cannot commit: %v", err)
+	}
+
+	conf := &config.Config{}
+	conf.DisabledTimestampPrefix = true
+
+	aggregate := &Aggregate{Output: t.TempDir()}
+	blogs, err := aggregate.collect(conf, 0, config.Source{Repo: "file://" + dir, Prefix: "notes"})
+	if err != nil {
+		t.Fatalf("cannot collect: %v", err)
+	}
+
+	site := &Clone{Output: aggregate.Output, blogs: blogs}
+	if err := site.Generate(conf); err != nil {
+		t.Fatalf("cannot generate: %v", err)
+	}
+
+	for name, expect := range map[string]string{
+		"notes-post.htm":  `<code class="language-go">package snip`,
+		"notes-other.htm": `href="notes-post.htm"`,
+	} {
+		text, err := os.ReadFile(filepath.Join(aggregate.Output, name))
+		if err != nil {
+			t.Fatalf("expect %v generated: %v", name, err)
+		}
+		if !strings.Contains(string(text), expect) {
+			t.Errorf("expect %v in %v:\n%s", expect, name, text)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package clone
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the environment variables of the HTTP/HTTPS auth
+	ENV_USERNAME = "GITUP_USERNAME"
+	ENV_PASSWORD = "GITUP_PASSWORD"
+	ENV_TOKEN    = "GITUP_TOKEN"
+	ENV_NETRC    = "NETRC"
+)
+
+// the credential of the HTTP/HTTPS repository
+type Credential struct {
+	Username string
+	Password string
+}
+
+// the redacted remote repository URI, never show the password in log
+func (clone *Clone) repository() (repo string) {
+	if clone.Repo != nil {
+		repo = clone.Repo.Redacted()
+	}
+	return
+}
+
+// the remote repository URI without the user info, the credential is passed
+// by the auth method
+func (clone *Clone) remote() (remote string) {
+	uri := *clone.Repo
+	uri.User = nil
+	remote = uri.String()
+	return
+}
+
+// resolve the HTTP/HTTPS auth method, the order is
+//
+//   - the bearer token from the flag or environment variable
+//   - the username/password from the flag or environment variable
+//   - the username/password in the repository URI
+//   - the machine in the netrc file
+//   - the git credential helper
+func (clone *Clone) auth() (auth transport.AuthMethod, err error) {
+	host := clone.Repo.Hostname()
+
+	switch {
+	case clone.Token != "":
+		log.WithFields(log.Fields{
+			"repository": clone.repository(),
+		}).Debug("auth with the bearer token")
+
+		auth = &http.TokenAuth{Token: clone.Token}
+		return
+	case clone.Username != "" || clone.Password != "":
+		log.WithFields(log.Fields{
+			"repository": clone.repository(),
+			"username":   clone.Username,
+		}).Debug("auth with the username/password")
+
+		auth = &http.BasicAuth{Username: clone.Username, Password: clone.Password}
+		return
+	case clone.Repo.User != nil:
+		password, _ := clone.Repo.User.Password()
+		auth = &http.BasicAuth{Username: clone.Repo.User.Username(), Password: password}
+		return
+	}
+
+	if clone.Netrc {
+		var cred *Credential
+		if cred, err = LookupNetrc(NetrcPath(), host); err != nil {
+			// cannot read the netrc file
+			return
+		}
+
+		if cred != nil {
+			log.WithFields(log.Fields{
+				"repository": clone.repository(),
+				"username":   cred.Username,
+			}).Debug("auth with the netrc")
+
+			auth = &http.BasicAuth{Username: cred.Username, Password: cred.Password}
+			return
+		}
+	}
+
+	if clone.CredentialHelper {
+		var cred *Credential
+		if cred, err = CredentialFill(clone.Repo); err != nil {
+			// cannot get the credential from helper
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"repository": clone.repository(),
+			"username":   cred.Username,
+		}).Debug("auth with the git credential helper")
+
+		auth = &http.BasicAuth{Username: cred.Username, Password: cred.Password}
+		return
+	}
+
+	// the anonymous access
+	return
+}
+
+// the path of the netrc file, from the environment variable or the home folder
+func NetrcPath() (path string) {
+	if path = os.Getenv(ENV_NETRC); path != "" {
+		return
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// cannot get the home folder
+		return
+	}
+
+	for _, name := range []string{".netrc", "_netrc"} {
+		path = filepath.Join(home, name)
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+	}
+
+	path = ""
+	return
+}
+
+// lookup the credential of the host from the netrc file, return nil if
+// not found
+func LookupNetrc(path, host string) (cred *Credential, err error) {
+	if path == "" {
+		// no netrc file
+		return
+	}
+
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		if os.IsNotExist(err) {
+			// the netrc file not exists
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+
+	cred, err = ParseNetrc(file, host)
+	return
+}
+
+// parse the netrc and find the credential of the host, fallback to the
+// default machine if exists
+func ParseNetrc(reader io.Reader, host string) (cred *Credential, err error) {
+	var tokens []string
+	var in_macro bool
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if in_macro {
+			// the macro body ends with the empty line
+			in_macro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		for _, word := range strings.Fields(line) {
+			if word == "macdef" {
+				// the macro is not supported, skip the name and the body
+				in_macro = true
+				break
+			}
+
+			tokens = append(tokens, word)
+		}
+	}
+
+	var matched, fallback *Credential
+	var current *Credential
+	for idx := 0; idx < len(tokens); idx++ {
+		switch token := tokens[idx]; token {
+		case "machine":
+			current = nil
+			if idx++; idx < len(tokens) && tokens[idx] == host && matched == nil {
+				matched = &Credential{}
+				current = matched
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &Credential{}
+				current = fallback
+			}
+		case "login", "password", "account":
+			if idx++; idx >= len(tokens) {
+				err = fmt.Errorf("missing value of %v in netrc", token)
+				return
+			}
+
+			switch {
+			case current == nil:
+			case token == "login":
+				current.Username = tokens[idx]
+			case token == "password":
+				current.Password = tokens[idx]
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		// cannot read the netrc
+		return
+	}
+
+	switch {
+	case matched != nil:
+		cred = matched
+	case fallback != nil:
+		cred = fallback
+	}
+	return
+}
+
+// get the credential from the git credential helper via `git credential fill`
+func CredentialFill(repo *url.URL) (cred *Credential, err error) {
+	var input bytes.Buffer
+
+	fmt.Fprintf(&input, "protocol=%v\n", repo.Scheme)
+	fmt.Fprintf(&input, "host=%v\n", repo.Host)
+	if path := strings.TrimPrefix(repo.Path, "/"); path != "" {
+		fmt.Fprintf(&input, "path=%v\n", path)
+	}
+	if repo.User != nil {
+		fmt.Fprintf(&input, "username=%v\n", repo.User.Username())
+	}
+	input.WriteString("\n")
+
+	var output bytes.Buffer
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	// never prompt in the terminal
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("git credential fill: %v", err)
+		return
+	}
+
+	cred, err = ParseCredential(&output)
+	return
+}
+
+// parse the key=value output of the git credential helper
+func ParseCredential(reader io.Reader) (cred *Credential, err error) {
+	cred = &Credential{}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			// not the key=value
+			continue
+		}
+
+		switch key, value := line[:idx], line[idx+1:]; key {
+		case "username":
+			cred.Username = value
+		case "password":
+			cred.Password = value
+		}
+	}
+
+	if err = scanner.Err(); err == nil && cred.Username == "" && cred.Password == "" {
+		err = fmt.Errorf("no credential from the git credential helper")
+	}
+	return
+}
@@ -1,28 +1,29 @@
 package clone
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/cmj0121/gitup/blog"
 	"github.com/cmj0121/gitup/config"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	SUFFIX_MD       = ".md"
-	SUFFIX_MARKDOWN = ".markdown"
-)
-
 // the clone instance
 type Clone struct {
 	// the remote repository URI
@@ -31,39 +32,52 @@ type Clone struct {
 	// the final destinate folder of the webpage
 	Output string `short:"o" type:"path" default:"build" help:"the destinate folder of the generated webpage"`
 
-	// Auth with username/password
-	Username string `short:"U" help:"the username used for auth"`
-	Password string `short:"P" help:"the password used for auth"`
-
-	// remove the temporary folder
-	Purge bool `short:"p" negatable:"" default:"true" help:"purge the temporary repo cloned from remote"`
+	// the options of cloning the repository
+	Options `embed:""`
+
+	tempdir    string               // the working space, empty when read from the git objects
+	local      string               // the path of the local repository
+	credential transport.AuthMethod // the resolved auth method
+	fsys       fs.FS                // the file system of the repository
+	truncated  bool                 // the git history is truncated
+	blogs      blog.Blogs           // the processed blog instances
+	refs       *references          // the blog/markdown sources to the output links
+	sparse     *object.Tree         // the HEAD tree to checkout the included files when sparse
+}
 
-	tempdir string     // the working space
-	blogs   blog.Blogs // the processed blog instances
+// the generated page in the sources manifest, the blog/markdown source and
+// the resolved links in the page to the original links in the source
+type ManifestPage struct {
+	Source string            `json:"source"`
+	Links  map[string]string `json:"links,omitempty"`
 }
 
 // clone the repository and generate the webpage
 func (clone *Clone) Run(config *config.Config) (err error) {
-	clone.tempdir = fmt.Sprintf("%v/gitup.%d", os.TempDir(), os.Getpid())
-	clone.tempdir = filepath.Clean(clone.tempdir)
-
-	defer func() {
-		if clone.Purge {
-			os.RemoveAll(clone.tempdir) // nolint
-		}
-	}()
+	defer clone.purge()
 
 	var repo *git.Repository
-	if repo, err = clone.Clone(); err != nil {
-		log.WithFields(log.Fields{
-			"repository": clone.Repo,
-			"error":      err,
-		}).Warn("clone repository")
+	if repo, err = clone.Open(config); err != nil {
+		// cannot open the repository
 		return
 	}
 
 	// load the customized config from repo
-	config.Load(clone.tempdir)
+	config.SetSource(clone.fsys)
+	if err = config.LoadFS(clone.fsys, "."); err != nil {
+		// invalid config in the repository
+		return
+	}
+
+	if err = config.CheckProfile(); err != nil {
+		// the profile is not defined
+		return
+	}
+
+	if err = config.LoadData(); err != nil {
+		// invalid data files
+		return
+	}
 
 	for _, dir := range config.Workdir {
 		if err = clone.Process(config, dir); err != nil {
@@ -74,22 +88,69 @@ func (clone *Clone) Run(config *config.Config) (err error) {
 		}
 	}
 
-	err = clone.Generate(config, repo)
+	if err = clone.Timestamp(repo); err != nil {
+		// cannot find the blogs timestamp
+		return
+	}
+
+	err = clone.Generate(config)
+	return
+}
+
+// clone or open the repository, and mount the file system of the repository
+func (clone *Clone) Open(config *config.Config) (repo *git.Repository, err error) {
+	if clone.tempdir == "" {
+		clone.tempdir = fmt.Sprintf("%v/gitup.%d", os.TempDir(), os.Getpid())
+		clone.tempdir = filepath.Clean(clone.tempdir)
+	}
+
+	if repo, err = clone.Clone(); err != nil {
+		log.WithFields(log.Fields{
+			"repository": clone.repository(),
+			"error":      err,
+		}).Warn("clone repository")
+		return
+	}
+
+	if clone.Sparse {
+		if err = clone.checkout_sparse(config, repo); err != nil {
+			log.WithFields(log.Fields{
+				"repository": clone.repository(),
+				"error":      err,
+			}).Warn("sparse checkout")
+			return
+		}
+	}
+
+	if err = clone.mount(repo); err != nil {
+		log.WithFields(log.Fields{
+			"repository": clone.repository(),
+			"error":      err,
+		}).Warn("cannot read the repository")
+		return
+	}
+
 	return
 }
 
+// remove the temporary working space
+func (clone *Clone) purge() {
+	if clone.Purge && clone.tempdir != "" {
+		os.RemoveAll(clone.tempdir) // nolint
+	}
+}
+
 // clone the repo to local temporary folder
 func (clone *Clone) Clone() (repo *git.Repository, err error) {
-	var auth transport.AuthMethod
 	switch scheme := clone.Repo.Scheme; scheme {
 	case "http", "https":
 		// generatl HTTP/HTTPS repository
-		auth = &http.BasicAuth{
-			Username: clone.Username,
-			Password: clone.Password,
+		if clone.credential, err = clone.auth(); err != nil {
+			// cannot resolve the credential
+			return
 		}
 	case "file":
-		path := clone.Repo.Host
+		path := clone.Repo.Host + clone.Repo.Path
 		if path == "" {
 			// change the path to current path
 			if path, err = os.Getwd(); err != nil {
@@ -109,41 +170,371 @@ func (clone *Clone) Clone() (repo *git.Repository, err error) {
 				"repo":  path,
 				"error": err,
 			}).Warn("cannot open repository")
+			return
 		}
 
 		// force reset the settings
 		clone.Purge = false
+		clone.Sparse = false
 		clone.tempdir = path
+		clone.local = path
+
+		switch _, err = repo.Worktree(); err {
+		case nil:
+			if clone.Submodules {
+				// init and update the submodules in the local repository
+				err = clone.update_submodules(repo)
+			}
+		case git.ErrIsBareRepository:
+			// the bare repository, read from the git objects
+			log.WithFields(log.Fields{
+				"repo": path,
+			}).Info("read the bare repository without checkout")
+
+			clone.tempdir = ""
+			err = nil
+		}
 		return
 	default:
 		err = fmt.Errorf("not support scheme: %v", scheme)
 		return
 	}
 
+	// the credential is passed by auth, never keep in the URL
+	remote := clone.remote()
+
+	if clone.Submodules && clone.Sparse {
+		// the submodules need the full checkout
+		log.Info("disable sparse checkout for the submodules")
+		clone.Sparse = false
+	}
+
 	// clone options
 	options := git.CloneOptions{
-		Auth: auth,
-		URL:  clone.Repo.String(),
+		Auth:         clone.credential,
+		URL:          remote,
+		Depth:        clone.Depth,
+		SingleBranch: clone.SingleBranch,
+		// checkout the necessary files later when sparse
+		NoCheckout: clone.Sparse,
+	}
+	if clone.Branch != "" {
+		options.ReferenceName = plumbing.NewBranchReferenceName(clone.Branch)
+	}
+	if clone.Submodules && !clone.Memory {
+		options.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+	}
+
+	switch clone.Memory {
+	case true:
+		// clone into memory and never checkout
+		clone.Sparse = false
+		clone.tempdir = ""
+		repo, err = git.Clone(memory.NewStorage(), nil, &options)
+	case false:
+		repo, err = git.PlainClone(clone.tempdir, false, &options)
+	}
+
+	return
+}
+
+// mount the file system of the repository, read from the working space if
+// checkout, otherwise read from the git objects of the HEAD commit
+func (clone *Clone) mount(repo *git.Repository) (err error) {
+	switch clone.tempdir {
+	case "":
+		var commit *object.Commit
+		if commit, err = clone.head_commit(repo); err != nil {
+			// cannot get the HEAD commit
+			return
+		}
+
+		var fsys *TreeFS
+		if fsys, err = NewTreeFS(commit); err != nil {
+			// cannot read the git tree
+			return
+		}
+
+		if clone.Submodules {
+			base := clone.local
+			if base == "" {
+				// the credential is passed by auth, never in the submodule URL
+				base = clone.remote()
+			}
+
+			if err = clone.mount_submodules(fsys, base, int(git.DefaultSubmoduleRecursionDepth)); err != nil {
+				// cannot mount the submodules
+				return
+			}
+		}
+
+		clone.fsys = fsys
+	default:
+		clone.fsys = os.DirFS(clone.tempdir)
+	}
+
+	if clone.LFS {
+		clone.fsys = &LFSFS{
+			FS:       clone.fsys,
+			Store:    clone.lfs_store(),
+			Endpoint: clone.lfs_endpoint(repo),
+			Auth:     clone.credential,
+		}
+	}
+
+	return
+}
+
+// the local git LFS object store
+func (clone *Clone) lfs_store() (store string) {
+	switch {
+	case clone.LFSStore != "":
+		store = clone.LFSStore
+	case clone.gitdir() != "":
+		store = filepath.Join(clone.gitdir(), "lfs", "objects")
+	}
+
+	return
+}
+
+// the git LFS server endpoint, derived from the HTTP/HTTPS remote
+func (clone *Clone) lfs_endpoint(repo *git.Repository) (endpoint string) {
+	if clone.LFSURL != "" {
+		endpoint = clone.LFSURL
+		return
+	}
+
+	remote := clone.Repo.String()
+	if clone.local != "" {
+		// the remote of the local repository
+		origin, err := repo.Remote(git.DefaultRemoteName)
+		if err != nil || len(origin.Config().URLs) == 0 {
+			return
+		}
+		remote = origin.Config().URLs[0]
+	}
+
+	uri, err := url.Parse(remote)
+	if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") {
+		// only support the HTTP/HTTPS endpoint
+		return
+	}
+
+	uri.User = nil
+	if !strings.HasSuffix(uri.Path, ".git") {
+		uri.Path += ".git"
+	}
+	uri.Path += "/info/lfs"
+
+	endpoint = uri.String()
+	return
+}
+
+// resolve the related path in the repository, and reject the path which
+// is outside the repository
+func (clone *Clone) resolve(name string) (path string, err error) {
+	path = filepath.ToSlash(filepath.Clean(name))
+
+	if !fs.ValidPath(path) {
+		err = fmt.Errorf("invalid path: %v", name)
+		return
+	}
+
+	return
+}
+
+// read the file included by the blog/markdown, the path is checked as the
+// blog/markdown sources and checked out on demand when sparse, and the
+// symlink in the working space is rejected
+func (clone *Clone) include(name string) (data []byte, err error) {
+	var path string
+	if path, err = clone.resolve(name); err != nil {
+		// the path outside the repository
+		return
+	}
+
+	if _, err = fs.Stat(clone.fsys, path); errors.Is(err, fs.ErrNotExist) && clone.sparse != nil {
+		if err = clone.checkout_paths(clone.sparse, []string{path}); err != nil {
+			// cannot checkout the included file
+			return
+		}
 	}
-	if repo, err = git.PlainClone(clone.tempdir, false, &options); err != nil {
-		// cannot clone from remote to local
+
+	if clone.tempdir != "" {
+		// the working space follows the symlink which may point outside the
+		// repository, the git objects never do
+		if err = blog.CheckSymlink(clone.tempdir, path); err != nil {
+			return
+		}
+	}
+
+	data, err = fs.ReadFile(clone.fsys, path)
+	return
+}
+
+// checkout the config, the workdir folders and the referenced files only
+func (clone *Clone) checkout_sparse(conf *config.Config, repo *git.Repository) (err error) {
+	var commit *object.Commit
+	if commit, err = clone.head_commit(repo); err != nil {
+		// cannot get the HEAD commit
+		return
+	}
+
+	var tree *object.Tree
+	if tree, err = commit.Tree(); err != nil {
+		// cannot get the HEAD tree
+		return
+	}
+	clone.sparse = tree
+
+	// checkout the config first, then load the workdir from the config
+	if err = clone.checkout_paths(tree, config.ConfigPath); err != nil {
+		// cannot checkout the config
+		return
+	}
+
+	local := *conf
+	if err = local.Load(clone.tempdir); err != nil {
+		// invalid config in the repository
 		return
 	}
 
+	paths := append([]string{}, local.Workdir...)
+	paths = append(paths, local.AboutMe, local.License, local.Favicon)
+	for _, page := range local.Pages {
+		paths = append(paths, page.Source)
+	}
+	paths = append(paths, local.Html, local.ListHtmp)
+	for _, style := range local.Style {
+		// the stylesheet and the imported partials in the same folder
+		paths = append(paths, style)
+		if dir := path.Dir(filepath.ToSlash(style)); dir != "." {
+			paths = append(paths, dir)
+		}
+	}
+	paths = append(paths, config.THEME_LAYOUTS)
+	if local.Theme != "" {
+		// the theme folder, or the name under the themes folder
+		paths = append(paths, local.Theme, path.Join(config.THEME_FOLDER, local.Theme))
+	}
+	paths = append(paths, config.DATA_FOLDER, config.I18N_FOLDER)
+	paths = append(paths, local.Assets...)
+
+	err = clone.checkout_paths(tree, paths)
+	return
+}
+
+// checkout the files or folders from the git tree to the working space
+func (clone *Clone) checkout_paths(tree *object.Tree, paths []string) (err error) {
+	for _, path := range paths {
+		if path == "" {
+			// not set
+			continue
+		}
+
+		path = filepath.ToSlash(filepath.Clean(path))
+		dest := filepath.Clean(fmt.Sprintf("%v/%v", clone.tempdir, path))
+		if dest[:len(clone.tempdir)] != clone.tempdir {
+			err = fmt.Errorf("invalid checkout path: %v", path)
+			return
+		}
+
+		switch path {
+		case ".":
+			// the whole repository
+			err = clone.checkout_tree(tree, "")
+		default:
+			if file, ferr := tree.File(path); ferr == nil {
+				err = clone.checkout_file(file, path)
+				break
+			}
+
+			var subtree *object.Tree
+			if subtree, err = tree.Tree(path); err != nil {
+				log.WithFields(log.Fields{
+					"path":  path,
+					"error": err,
+				}).Debug("path not found in repository")
+
+				err = nil
+				continue
+			}
+
+			err = clone.checkout_tree(subtree, path)
+		}
+
+		if err != nil {
+			// cannot checkout file
+			return
+		}
+	}
+
+	return
+}
+
+// checkout all the files in the git tree, the prefix is the related path
+func (clone *Clone) checkout_tree(tree *object.Tree, prefix string) (err error) {
+	err = tree.Files().ForEach(func(file *object.File) (err error) {
+		name := file.Name
+		if prefix != "" {
+			name = fmt.Sprintf("%v/%v", prefix, file.Name)
+		}
+
+		err = clone.checkout_file(file, name)
+		return
+	})
+	return
+}
+
+// write the single file from the git tree to the working space
+func (clone *Clone) checkout_file(file *object.File, name string) (err error) {
+	if !file.Mode.IsFile() {
+		// skip the non-regular file, like submodule
+		return
+	}
+
+	path := filepath.Clean(fmt.Sprintf("%v/%v", clone.tempdir, name))
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		// cannot create the parent folder
+		return
+	}
+
+	var text string
+	if text, err = file.Contents(); err != nil {
+		// cannot read the file from git object
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"path": name,
+	}).Trace("sparse checkout file")
+
+	err = os.WriteFile(path, []byte(text), 0640)
+	return
+}
+
+// get the HEAD commit of the repository
+func (clone *Clone) head_commit(repo *git.Repository) (commit *object.Commit, err error) {
+	var ref *plumbing.Reference
+	if ref, err = repo.Head(); err != nil {
+		// cannot get the HEAD reference
+		return
+	}
+
+	commit, err = repo.CommitObject(ref.Hash())
 	return
 }
 
 // process and generate HTML from specified folder
 func (clone *Clone) Process(config *config.Config, dir string) (err error) {
-	path := filepath.Clean(fmt.Sprintf("%v/%v", clone.tempdir, dir))
-
-	if path[:len(clone.tempdir)] != clone.tempdir {
-		err = fmt.Errorf("invalid folder path: %v", path)
+	var path string
+	if path, err = clone.resolve(dir); err != nil {
+		err = fmt.Errorf("invalid folder path: %v", dir)
 		return
 	}
 
-	var files []os.DirEntry
-	if files, err = os.ReadDir(path); err != nil {
+	var files []fs.DirEntry
+	if files, err = fs.ReadDir(clone.fsys, path); err != nil {
 		log.WithFields(log.Fields{
 			"path":  path,
 			"error": err,
@@ -157,15 +548,17 @@ func (clone *Clone) Process(config *config.Config, dir string) (err error) {
 		switch {
 		case name[0] == '.':
 			// the hidden file, skip
-		case strings.HasSuffix(name, SUFFIX_MD) || strings.HasSuffix(name, SUFFIX_MARKDOWN):
-			// parse the blog/markdown
-			md_path := fmt.Sprintf("%v/%v", path, name)
-			md_path = filepath.Clean(md_path)
-
-			if md_path[:len(path)] != path {
+		case file.IsDir():
+			// the sub-folder, skip
+		case is_source(config, name):
+			// parse the blog/markdown, or the other source format
+			var md_path string
+			if md_path, err = clone.resolve(fmt.Sprintf("%v/%v", path, name)); err != nil {
 				log.WithFields(log.Fields{
-					"path": md_path,
+					"path": name,
 				}).Info("invalid blog/markdown path")
+
+				err = nil
 				continue
 			}
 
@@ -175,6 +568,13 @@ func (clone *Clone) Process(config *config.Config, dir string) (err error) {
 				return
 			}
 
+			if md_blog.Meta.Draft && !config.Drafts {
+				log.WithFields(log.Fields{
+					"path": md_path,
+				}).Info("skip the draft blog/markdown")
+				continue
+			}
+
 			clone.blogs = append(clone.blogs, md_blog)
 		}
 	}
@@ -182,8 +582,34 @@ func (clone *Clone) Process(config *config.Config, dir string) (err error) {
 	return
 }
 
+// the file is the blog/markdown, or the source format registered or
+// converted by the external command
+func is_source(conf *config.Config, name string) (ok bool) {
+	_, ok = blog.FindFormat(conf, name)
+	return
+}
+
+// find the blogs timestamp from the git history, and fallback to the front
+// matter or the file mtime
+func (clone *Clone) Timestamp(repo *git.Repository) (err error) {
+	if err = clone.find_first_commit(repo, clone.blogs); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("cannot find the blogs first commit time")
+		return
+	}
+
+	clone.fallback_timestamp(clone.blogs)
+	return
+}
+
 // generate the final webpage
-func (clone *Clone) Generate(config *config.Config, repo *git.Repository) (err error) {
+func (clone *Clone) Generate(config *config.Config) (err error) {
+	if len(clone.blogs) == 0 {
+		err = fmt.Errorf("no blog/markdown found")
+		return
+	}
+
 	if _, err := os.Stat(clone.Output); err == nil {
 		// always remove the description folder if exists
 		os.RemoveAll(clone.Output) // nolint
@@ -197,28 +623,52 @@ func (clone *Clone) Generate(config *config.Config, repo *git.Repository) (err e
 		return
 	}
 
-	// sort by the blog
-	sort.Sort(clone.blogs)
-	if err = clone.find_first_commit(repo, clone.blogs); err != nil {
+	if err = config.WriteStyle(clone.Output); err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
-		}).Warn("cannot find the blogs first commit time")
+		}).Warn("cannot generate the stylesheet")
 		return
 	}
 
 	summary := clone.blogs.SummaryByYear(config)
-	for _, blog := range clone.blogs {
-		basename := filepath.Base(filepath.Clean(blog.Path))
-		basename = basename[:len(basename)-len(filepath.Ext(basename))]
 
-		var dest_path string
-		switch config.IsHidden(blog.Path) || config.DisabledTimestampPrefix {
-		case true:
-			dest_path = fmt.Sprintf("%v/%v.htm", clone.Output, basename)
-		case false:
-			dest_path = fmt.Sprintf("%v/%v-%v.htm", clone.Output, blog.UID(), basename)
+	// resolve the name collision in the stable order, the earlier blog keeps
+	// the name and the later one has the -2, -3 ... suffix
+	ordered := append(blog.Blogs{}, clone.blogs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		switch x, y := ordered[i], ordered[j]; {
+		case !x.CreatedAt.Equal(y.CreatedAt):
+			return x.CreatedAt.Before(y.CreatedAt)
+		default:
+			return x.Path < y.Path
+		}
+	})
+
+	// the reserved names of the default pages
+	names := map[string]struct{}{}
+	for _, name := range config.ReservedNames() {
+		names[name] = struct{}{}
+	}
+	for _, blog := range ordered {
+		name := blog.Name(config)
+
+		unique := name
+		for idx := 2; ; idx++ {
+			if _, ok := names[unique]; !ok {
+				break
+			}
+
+			unique = fmt.Sprintf("%v-%d", name, idx)
+		}
+		if unique != name {
+			log.WithFields(log.Fields{
+				"path": blog.Path,
+				"name": unique,
+			}).Warn("rename the blog with the name collision")
 		}
+		names[unique] = struct{}{}
 
+		dest_path := fmt.Sprintf("%v/%v.htm", clone.Output, unique)
 		dest_path = filepath.Clean(dest_path)
 		if dest_path[:len(clone.Output)] != clone.Output {
 			err = fmt.Errorf("invalid desc path: %v", dest_path)
@@ -229,7 +679,13 @@ func (clone *Clone) Generate(config *config.Config, repo *git.Repository) (err e
 		blog.Link = blog.Output[len(clone.Output)+1:]
 	}
 
+	clone.refs = clone.references(config)
 	for _, blog := range clone.blogs {
+		if err = clone.refs.resolve_blog(config, blog); err != nil {
+			// the unresolved reference in the strict mode
+			return
+		}
+
 		if err = blog.Write(config, summary); err != nil {
 			// cannot write to description
 			return
@@ -246,8 +702,8 @@ func (clone *Clone) process(config *config.Config, path string) (md_blog *blog.B
 		"path": path,
 	}).Trace("process the blog/markdown")
 
-	var file *os.File
-	if file, err = os.Open(path); err != nil {
+	var file fs.File
+	if file, err = clone.fsys.Open(path); err != nil {
 		log.WithFields(log.Fields{
 			"path":  path,
 			"error": err,
@@ -256,15 +712,25 @@ func (clone *Clone) process(config *config.Config, path string) (md_blog *blog.B
 	}
 	defer file.Close()
 
-	if md_blog, err = blog.New(file); err != nil {
+	format, ok := blog.FindFormat(config, path)
+	if !ok {
+		// the standalone page is the blog/markdown by default
+		format = blog.Markdown{}
+	}
+	if _, external := format.(blog.Command); external && !clone.Converters {
+		err = fmt.Errorf("the external converter of %v is not allowed without --converters", path)
+		return
+	}
+
+	if md_blog, err = blog.NewWithFormat(file, path, format); err != nil {
 		log.WithFields(log.Fields{
 			"path":  path,
 			"error": err,
 		}).Info("cannot gen blog/markdown")
 		return
 	}
-	// only record the related path of the blog/markdown
-	md_blog.Path = path[len(clone.tempdir)+1:]
+	md_blog.SetConfig(config)
+	md_blog.SetIncluder(clone.include)
 	if _, err = md_blog.RenderHTML(); err != nil {
 		// cannot render HTML from blog
 		return
@@ -281,6 +747,21 @@ func (clone *Clone) find_first_commit(repo *git.Repository, blogs blog.Blogs) (e
 		md_path_idx_map[blog.Path] = idx
 	}
 
+	// the boundary commits of the shallow clone, which parents are not fetched
+	var shallows []plumbing.Hash
+	if shallows, err = repo.Storer.Shallow(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("cannot get the shallow commits")
+		return
+	}
+
+	boundary := map[plumbing.Hash]struct{}{}
+	for _, hash := range shallows {
+		boundary[hash] = struct{}{}
+	}
+	clone.truncated = len(boundary) > 0
+
 	options := git.LogOptions{
 		// only trace the file with the blog list
 		PathFilter: func(path string) (ok bool) {
@@ -300,6 +781,11 @@ func (clone *Clone) find_first_commit(repo *git.Repository, blogs blog.Blogs) (e
 	}
 
 	err = commit_iter.ForEach(func(commit *object.Commit) (err error) {
+		if _, ok := boundary[commit.Hash]; ok {
+			// the parents are truncated and cannot get the changes
+			return
+		}
+
 		var stats object.FileStats
 
 		if stats, err = commit.Stats(); err != nil {
@@ -329,9 +815,101 @@ func (clone *Clone) find_first_commit(repo *git.Repository, blogs blog.Blogs) (e
 		return
 	})
 
+	if clone.truncated {
+		if err == plumbing.ErrObjectNotFound {
+			// reach the truncated history
+			err = nil
+		}
+
+		// the blogs exist in the boundary commit may be created before
+		// and need to fallback
+		for hash := range boundary {
+			var commit *object.Commit
+			if commit, err = repo.CommitObject(hash); err != nil {
+				// cannot get the boundary commit
+				return
+			}
+
+			if err = clone.mark_truncated(commit, blogs); err != nil {
+				// cannot mark the truncated blogs
+				return
+			}
+		}
+	}
+
 	return
 }
 
+// mark the blogs exist in the shallow boundary commit as truncated
+func (clone *Clone) mark_truncated(commit *object.Commit, blogs blog.Blogs) (err error) {
+	var tree *object.Tree
+	if tree, err = commit.Tree(); err != nil {
+		log.WithFields(log.Fields{
+			"commit": commit,
+			"error":  err,
+		}).Warn("cannot get commit tree")
+		return
+	}
+
+	for _, blog := range blogs {
+		if _, ferr := tree.File(blog.Path); ferr == nil {
+			log.WithFields(log.Fields{
+				"path":   blog.Path,
+				"commit": commit.Hash,
+			}).Debug("the blog history is truncated")
+
+			// the created time is unknown, and the updated time is unknown
+			// if never changed after the boundary commit
+			blog.CreatedAt = time.Time{}
+		}
+	}
+
+	return
+}
+
+// fallback the blog timestamp from the front matter or the file mtime when
+// the git history cannot provide it
+func (clone *Clone) fallback_timestamp(blogs blog.Blogs) {
+	for _, blog := range blogs {
+		if !blog.Meta.Date.IsZero() {
+			// the explicit timestamp always has the highest priority
+			blog.CreatedAt = blog.Meta.Date
+		}
+		if !blog.Meta.Updated.IsZero() {
+			blog.UpdatedAt = blog.Meta.Updated
+		}
+
+		if !blog.CreatedAt.IsZero() && !blog.UpdatedAt.IsZero() {
+			// the timestamp is complete
+			continue
+		}
+
+		info, err := fs.Stat(clone.fsys, blog.Path)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"path":  blog.Path,
+				"error": err,
+			}).Info("cannot get the blog mtime")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"path":      blog.Path,
+			"truncated": clone.truncated,
+		}).Debug("fallback the blog timestamp to mtime")
+
+		if blog.CreatedAt.IsZero() {
+			blog.CreatedAt = info.ModTime()
+		}
+		if blog.UpdatedAt.IsZero() {
+			blog.UpdatedAt = info.ModTime()
+		}
+		if blog.UpdatedAt.Before(blog.CreatedAt) {
+			blog.UpdatedAt = blog.CreatedAt
+		}
+	}
+}
+
 // generate the default pages
 func (clone *Clone) generate_default_pages(config *config.Config, summary blog.Summary) (err error) {
 	sort.Sort(clone.blogs)
@@ -354,43 +932,106 @@ func (clone *Clone) generate_default_pages(config *config.Config, summary blog.S
 		return
 	}
 
-	if config.Settings.AboutMe != "" {
-		err = clone.generate_default_page(config, summary, config.Settings.AboutMe, "about-me.htm")
-		if err != nil {
-			// cannot render about-me.htm
+	for _, page := range config.NavPages() {
+		if err = clone.generate_default_page(config, summary, page); err != nil {
+			// cannot render the standalone page
 			return
 		}
 	}
-	if config.Settings.License != "" {
-		err = clone.generate_default_page(config, summary, config.Settings.License, "license.htm")
-		if err != nil {
-			// cannot render about-me.htm
-			return
-		}
+
+	if err = clone.generate_manifest(config); err != nil {
+		// cannot write the sources manifest
+		return
+	}
+
+	if err = clone.generate_favicon(config); err != nil {
+		// cannot write the favicon
+		return
+	}
+
+	if err = clone.generate_assets(config); err != nil {
+		// cannot copy the assets
+		return
+	}
+
+	if err = clone.postprocess(config); err != nil {
+		// cannot post-process the outputs
+		return
 	}
 
-	err = clone.generate_favicon(config)
+	err = clone.compress()
 	return
 }
 
-func (clone *Clone) generate_default_page(config *config.Config, summary blog.Summary, src, dest string) (err error) {
+// render the standalone page, like the about-me
+func (clone *Clone) generate_default_page(conf *config.Config, summary blog.Summary, page config.Page) (err error) {
 	var md_blog *blog.Blog
 
-	md_path := fmt.Sprintf("%v/%v", clone.tempdir, src)
-	md_path = filepath.Clean(md_path)
-	if md_blog, err = clone.process(config, md_path); err != nil {
-		// cannot load about-me.htm
+	var md_path string
+	if md_path, err = clone.resolve(page.Source); err != nil {
+		// invalid page path
+		return
+	}
+
+	dest := page.Link()
+	if strings.ContainsAny(dest, `/\`) {
+		err = fmt.Errorf("invalid page output: %v", dest)
+		return
+	}
+
+	if md_blog, err = clone.process(conf, md_path); err != nil {
+		// cannot load the page
+		return
+	}
+
+	if err = clone.refs.resolve_blog(conf, md_blog); err != nil {
+		// the unresolved reference in the strict mode
 		return
 	}
 
 	md_blog.Output = fmt.Sprintf("%v/%v", clone.Output, dest)
 	md_blog.Output = filepath.Clean(md_blog.Output)
 	md_blog.Link = dest
-	err = md_blog.Write(config, summary)
+	md_blog.Layout = page.Layout
+	err = md_blog.Write(conf, summary)
 
 	return
 }
 
+// write the manifest of the generated pages to the blog/markdown sources, so
+// the problem found in the output can be reported with the source path
+func (clone *Clone) generate_manifest(conf *config.Config) (err error) {
+	if clone.Manifest == "" {
+		// not required, the repository layout is not published by default
+		return
+	}
+
+	sources := map[string]ManifestPage{}
+	add := func(link, source string) {
+		sources[link] = ManifestPage{Source: source, Links: clone.refs.links[source]}
+	}
+
+	// the newest post is also rendered as the index.htm
+	add("index.htm", clone.blogs[0].Path)
+	for _, blog := range clone.blogs {
+		add(blog.Link, blog.Path)
+	}
+	for _, page := range conf.NavPages() {
+		if src, err := clone.resolve(page.Source); err == nil {
+			add(page.Link(), src)
+		}
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(sources, "", "  "); err != nil {
+		// cannot generate the manifest
+		return
+	}
+
+	err = os.WriteFile(clone.Manifest, data, 0640)
+	return
+}
+
 func (clone *Clone) generate_favicon(conf *config.Config) (err error) {
 	var favicon []byte
 
@@ -398,10 +1039,13 @@ func (clone *Clone) generate_favicon(conf *config.Config) (err error) {
 	case "":
 		favicon = config.DEFAULT_FAVICON
 	default:
-		src := fmt.Sprintf("%v/%v", clone.tempdir, conf.Favicon)
-		src = filepath.Clean(src)
+		var src string
+		if src, err = clone.resolve(conf.Favicon); err != nil {
+			// invalid favicon path
+			return
+		}
 
-		if favicon, err = os.ReadFile(src); err != nil {
+		if favicon, err = fs.ReadFile(clone.fsys, src); err != nil {
 			log.WithFields(log.Fields{
 				"path":  src,
 				"error": err,
@@ -416,3 +1060,116 @@ func (clone *Clone) generate_favicon(conf *config.Config) (err error) {
 	err = os.WriteFile(path, favicon, 0640)
 	return
 }
+
+// copy the static files or folders to the destination, the theme-provided
+// assets are copied first and may be overridden by the site assets
+func (clone *Clone) generate_assets(conf *config.Config) (err error) {
+	if err = clone.generate_theme_assets(conf); err != nil {
+		// cannot copy the theme assets
+		return
+	}
+
+	for _, asset := range conf.Assets {
+		var src string
+		if src, err = clone.resolve(asset); err != nil {
+			// invalid asset path
+			return
+		}
+
+		err = fs.WalkDir(clone.fsys, src, func(path string, entry fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case entry.Name()[0] == '.' && path != src:
+				// skip the hidden file or folder
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			case entry.IsDir():
+				return nil
+			}
+
+			return clone.generate_asset(path, path)
+		})
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"path":  asset,
+				"error": err,
+			}).Warn("cannot copy the asset")
+			return
+		}
+	}
+
+	return
+}
+
+// copy the static assets of the theme to the destination, with the path
+// related to the static folder of the theme
+func (clone *Clone) generate_theme_assets(conf *config.Config) (err error) {
+	dir := conf.ThemeDir()
+	if dir == "" {
+		// the default theme has no static asset
+		return
+	}
+
+	static := path.Join(dir, config.THEME_STATIC)
+	if _, err = fs.Stat(clone.fsys, static); err != nil {
+		// the theme has no static asset
+		err = nil
+		return
+	}
+
+	err = fs.WalkDir(clone.fsys, static, func(src string, entry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case entry.Name()[0] == '.' && src != static:
+			// skip the hidden file or folder
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		case entry.IsDir():
+			return nil
+		}
+
+		return clone.generate_asset(src, src[len(static)+1:])
+	})
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  static,
+			"error": err,
+		}).Warn("cannot copy the theme asset")
+	}
+	return
+}
+
+// copy the single static file to the destination with the related name
+func (clone *Clone) generate_asset(src, name string) (err error) {
+	dest := filepath.Clean(fmt.Sprintf("%v/%v", clone.Output, name))
+	if dest[:len(clone.Output)] != clone.Output {
+		err = fmt.Errorf("invalid desc path: %v", dest)
+		return
+	}
+
+	var data []byte
+	if data, err = fs.ReadFile(clone.fsys, src); err != nil {
+		// cannot read the asset, may the invalid git LFS object
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		// cannot create the parent folder
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"path": src,
+	}).Trace("copy the asset")
+
+	err = os.WriteFile(dest, data, 0640)
+	return
+}
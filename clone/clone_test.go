@@ -0,0 +1,142 @@
+package clone
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestGenerateCollision(t *testing.T) {
+	now := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	clone := &Clone{
+		Output: t.TempDir(),
+		fsys:   fstest.MapFS{},
+	}
+
+	for _, path := range []string{"team-b/posts/example.md", "team-a/posts/example.md", "posts/index.md"} {
+		md_blog, err := blog.New(strings.NewReader("# The mock markdown post #\n"))
+		if err != nil {
+			t.Fatalf("cannot create blog: %v", err)
+		}
+
+		md_blog.Path = path
+		md_blog.CreatedAt = now
+		md_blog.UpdatedAt = now
+		clone.blogs = append(clone.blogs, md_blog)
+	}
+
+	conf := &config.Config{}
+	conf.DisabledTimestampPrefix = true
+	if err := clone.Generate(conf); err != nil {
+		t.Fatalf("cannot generate: %v", err)
+	}
+
+	expect := map[string]string{
+		"team-a/posts/example.md": "example.htm",
+		"team-b/posts/example.md": "example-2.htm",
+		"posts/index.md":          "index-2.htm",
+	}
+	for _, blog := range clone.blogs {
+		if blog.Link != expect[blog.Path] {
+			t.Errorf("expect %v link to %v: %v", blog.Path, expect[blog.Path], blog.Link)
+		}
+
+		if _, err := os.Stat(blog.Output); err != nil {
+			t.Errorf("expect %v generated: %v", blog.Output, err)
+		}
+	}
+}
+
+func TestProcessFormats(t *testing.T) {
+	clone := &Clone{
+		fsys: fstest.MapFS{
+			"posts/post.md":       {Data: []byte("# The Post\n")},
+			"posts/page.html":     {Data: []byte("<h1>The Page</h1>\n")},
+			"posts/doc.adoc":      {Data: []byte("= The Doc\n")},
+			"posts/image.png":     {Data: []byte("not the blog")},
+			"posts/.hidden.ipynb": {Data: []byte("{}")},
+		},
+	}
+
+	conf := &config.Config{}
+	conf.Formats = map[string]config.Converter{
+		".adoc": {Command: []string{"sed", "s/^= /# /"}, Output: config.CONVERTER_OUTPUT_MARKDOWN},
+	}
+
+	if err := clone.Process(conf, "posts"); err == nil {
+		t.Fatalf("expect the external converter is not allowed by default")
+	}
+
+	clone.blogs = nil
+	clone.Converters = true
+	if err := clone.Process(conf, "posts"); err != nil {
+		t.Fatalf("cannot process: %v", err)
+	}
+
+	titles := map[string]string{}
+	for _, md_blog := range clone.blogs {
+		titles[md_blog.Path] = md_blog.Title
+	}
+
+	expect := map[string]string{
+		"posts/post.md":   "The Post",
+		"posts/page.html": "The Page",
+		"posts/doc.adoc":  "The Doc",
+	}
+	if len(titles) != len(expect) {
+		t.Errorf("unexpected blogs: %v", titles)
+	}
+	for path, title := range expect {
+		if titles[path] != title {
+			t.Errorf("expect the title %v of %v: %v", title, path, titles[path])
+		}
+	}
+}
+
+func TestProcessInclude(t *testing.T) {
+	clone := &Clone{
+		fsys: fstest.MapFS{
+			"posts/post.md": {Data: []byte("# The Post\n\n{{< include /cmd/main.go lines=3 >}}\n")},
+			"posts/bad.md":  {Data: []byte("# The Bad\n\n{{< include ../../secret >}}\n")},
+			"cmd/main.go":   {Data: []byte("package main\n\nfunc main() {}\n")},
+		},
+	}
+	conf := &config.Config{}
+
+	md_blog, err := clone.process(conf, "posts/post.md")
+	if err != nil {
+		t.Fatalf("cannot process: %v", err)
+	}
+	if html := md_blog.HTML(); !strings.Contains(html, `<code class="language-go">`) {
+		t.Errorf("expect the included code block:\n%v", html)
+	}
+
+	if _, err := clone.process(conf, "posts/bad.md"); err == nil || !strings.Contains(err.Error(), "invalid path: ../secret") {
+		t.Errorf("expect the path outside the repository rejected: %v", err)
+	}
+}
+
+func TestProcessIncludeSymlink(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("the secret"), 0640)                                 // nolint
+	os.MkdirAll(filepath.Join(dir, "posts"), 0750)                                                                 // nolint
+	os.WriteFile(filepath.Join(dir, "posts", "leak.md"), []byte("# Leak\n\n{{< include \"leak.txt\" >}}\n"), 0640) // nolint
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "posts", "leak.txt")); err != nil {
+		t.Skipf("cannot create the symlink: %v", err)
+	}
+
+	clone := &Clone{tempdir: dir, fsys: os.DirFS(dir)}
+	md_blog, err := clone.process(&config.Config{}, "posts/leak.md")
+	switch {
+	case err == nil:
+		t.Errorf("expect the symlink rejected: %v", md_blog.HTML())
+	case !strings.Contains(err.Error(), "the symlink is not allowed: posts/leak.txt"):
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package clone
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// the text outputs can be pre-compressed, by the extension
+	COMPRESS_EXTS = []string{
+		".htm", ".html", ".css", ".js", ".json", ".xml", ".svg", ".txt", ".md",
+	}
+
+	// the suffix of the pre-compressed sibling by the encoding
+	COMPRESS_SUFFIXES = map[string]string{
+		"gzip": ".gz",
+		"br":   ".br",
+	}
+)
+
+// write the pre-compressed siblings of the text outputs, like index.htm.gz,
+// so the static server can serve them directly
+func (clone *Clone) compress() (err error) {
+	if len(clone.Compress) == 0 {
+		// nothing to do
+		return
+	}
+
+	var files []string
+	err = filepath.WalkDir(clone.Output, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && is_compressible(path) {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		// cannot list the outputs
+		return
+	}
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for idx := 0; idx < runtime.NumCPU(); idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				clone.compress_file(file)
+			}
+		}()
+	}
+
+	for _, file := range files {
+		queue <- file
+	}
+	close(queue)
+
+	wg.Wait()
+	return
+}
+
+// compress the single output by all the encodings, skipped when the output is
+// smaller than the threshold or the compressed one is not smaller
+func (clone *Clone) compress_file(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  file,
+			"error": err,
+		}).Warn("cannot read the output")
+		return
+	}
+
+	if len(data) < clone.CompressThreshold {
+		// too small to compress
+		return
+	}
+
+	for _, encoding := range clone.Compress {
+		compressed, err := compress_data(encoding, data)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"path":     file,
+				"encoding": encoding,
+				"error":    err,
+			}).Warn("cannot compress the output")
+			continue
+		}
+
+		if len(compressed) >= len(data) {
+			log.WithFields(log.Fields{
+				"path":     file,
+				"encoding": encoding,
+			}).Debug("skip the compressed output not smaller")
+			continue
+		}
+
+		dest := file + COMPRESS_SUFFIXES[encoding]
+		if err := os.WriteFile(dest, compressed, 0640); err != nil {
+			log.WithFields(log.Fields{
+				"path":  dest,
+				"error": err,
+			}).Warn("cannot write the compressed output")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"path":       dest,
+			"size":       len(data),
+			"compressed": len(compressed),
+		}).Trace("compress the output")
+	}
+}
+
+// compress the data by the encoding with the best compression
+func compress_data(encoding string, data []byte) (compressed []byte, err error) {
+	var buff bytes.Buffer
+	var writer io.WriteCloser
+
+	switch encoding {
+	case "br":
+		writer = brotli.NewWriterLevel(&buff, brotli.BestCompression)
+	default:
+		// never fails on the valid level
+		writer, _ = gzip.NewWriterLevel(&buff, gzip.BestCompression) // nolint
+	}
+
+	if _, err = writer.Write(data); err != nil {
+		// cannot compress the data
+		return
+	}
+
+	if err = writer.Close(); err != nil {
+		// cannot flush the compressed data
+		return
+	}
+
+	compressed = buff.Bytes()
+	return
+}
+
+// the text output can be pre-compressed
+func is_compressible(file string) (ok bool) {
+	ext := strings.ToLower(path.Ext(file))
+	for _, compress_ext := range COMPRESS_EXTS {
+		if ext == compress_ext {
+			ok = true
+			break
+		}
+	}
+	return
+}
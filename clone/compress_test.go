@@ -0,0 +1,66 @@
+package clone
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCompress(t *testing.T) {
+	output := t.TempDir()
+	files := map[string]string{
+		"index.htm":    strings.Repeat("<p>the blog content</p>\n", 128),
+		"css/site.css": strings.Repeat("body { color: #333; }\n", 128),
+		"small.htm":    "<p>small</p>",
+		"logo.png":     strings.Repeat("the PNG image", 128),
+		"random.txt":   "0123456789abcdefghijklmnopqrstuvwxyz",
+	}
+	for name, text := range files {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0750)  // nolint
+		os.WriteFile(path, []byte(text), 0640) // nolint
+	}
+
+	clone := &Clone{Output: output}
+	clone.Compress = []string{"gzip", "br"}
+	clone.CompressThreshold = 32
+	if err := clone.compress(); err != nil {
+		t.Fatalf("cannot compress: %v", err)
+	}
+
+	for _, name := range []string{"index.htm", "css/site.css"} {
+		path := filepath.Join(output, filepath.FromSlash(name))
+
+		data, err := os.ReadFile(path + ".gz")
+		if err != nil {
+			t.Fatalf("expect the gzip output of %v: %v", name, err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("invalid gzip output of %v: %v", name, err)
+		}
+		if text, _ := io.ReadAll(reader); string(text) != files[name] {
+			t.Errorf("unexpected gzip content of %v", name)
+		}
+
+		if data, err = os.ReadFile(path + ".br"); err != nil {
+			t.Fatalf("expect the brotli output of %v: %v", name, err)
+		}
+		if text, _ := io.ReadAll(brotli.NewReader(bytes.NewReader(data))); string(text) != files[name] {
+			t.Errorf("unexpected brotli content of %v", name)
+		}
+	}
+
+	// below the threshold, not the text output and not smaller
+	for _, name := range []string{"small.htm.gz", "logo.png.gz", "random.txt.gz"} {
+		if _, err := os.Stat(filepath.Join(output, name)); err == nil {
+			t.Errorf("unexpected compressed output: %v", name)
+		}
+	}
+}
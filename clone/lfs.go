@@ -0,0 +1,299 @@
+package clone
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the first line of the git LFS pointer
+	LFS_POINTER_VERSION = "version https://git-lfs.github.com/spec/v1"
+	// the max size of the git LFS pointer file
+	LFS_POINTER_MAX_SIZE = 1024
+	// the media type of the git LFS batch API
+	LFS_MEDIA_TYPE = "application/vnd.git-lfs+json"
+)
+
+// the git LFS pointer
+type LFSPointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// parse the git LFS pointer, return false if not the pointer file
+func ParseLFSPointer(data []byte) (pointer LFSPointer, ok bool) {
+	if len(data) > LFS_POINTER_MAX_SIZE || !bytes.HasPrefix(data, []byte(LFS_POINTER_VERSION)) {
+		// not the git LFS pointer
+		return
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "oid":
+			if strings.HasPrefix(fields[1], "sha256:") {
+				pointer.Oid = fields[1][len("sha256:"):]
+			}
+		case "size":
+			pointer.Size, _ = strconv.ParseInt(fields[1], 10, 64) // nolint
+		}
+	}
+
+	ok = len(pointer.Oid) == sha256.Size*2
+	return
+}
+
+// the file system resolves the git LFS pointer to the real content, from
+// the local LFS object store or the LFS server
+type LFSFS struct {
+	fs.FS
+
+	// the local LFS object store, like .git/lfs/objects
+	Store string
+	// the LFS server endpoint, like https://example.com/blog.git/info/lfs
+	Endpoint string
+	// the auth method of the LFS server
+	Auth   transport.AuthMethod
+	Client *http.Client
+
+	sync.Mutex
+	cache map[string][]byte
+}
+
+// open the file and resolve if it is the git LFS pointer
+func (fsys *LFSFS) Open(name string) (file fs.File, err error) {
+	if file, err = fsys.FS.Open(name); err != nil {
+		// cannot open file
+		return
+	}
+
+	var info fs.FileInfo
+	if info, err = file.Stat(); err != nil {
+		// cannot stat file
+		file.Close()
+		file = nil
+		return
+	}
+
+	if info.IsDir() || info.Size() > LFS_POINTER_MAX_SIZE {
+		// not the git LFS pointer
+		return
+	}
+
+	var data []byte
+	data, err = io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		// cannot read file
+		file = nil
+		return
+	}
+
+	pointer, ok := ParseLFSPointer(data)
+	if ok {
+		log.WithFields(log.Fields{
+			"path": name,
+			"oid":  pointer.Oid,
+		}).Debug("resolve the git LFS pointer")
+
+		if data, err = fsys.Fetch(pointer); err != nil {
+			file = nil
+			err = &fs.PathError{Op: "open", Path: name, Err: err}
+			return
+		}
+	}
+
+	file = &lfsFile{FileInfo: info, size: int64(len(data)), Reader: bytes.NewReader(data)}
+	return
+}
+
+// fetch the git LFS object, from the local store first
+func (fsys *LFSFS) Fetch(pointer LFSPointer) (data []byte, err error) {
+	fsys.Lock()
+	defer fsys.Unlock()
+
+	if data, ok := fsys.cache[pointer.Oid]; ok {
+		// the cached object
+		return data, nil
+	}
+
+	switch data, err = fsys.fetch_local(pointer); {
+	case err == nil:
+	case os.IsNotExist(err) && fsys.Endpoint != "":
+		data, err = fsys.fetch_remote(pointer)
+	}
+
+	if err != nil {
+		// cannot fetch the object
+		return
+	}
+
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != pointer.Oid {
+		err = fmt.Errorf("mismatch git LFS object: %v", pointer.Oid)
+		return
+	}
+
+	if fsys.cache == nil {
+		fsys.cache = map[string][]byte{}
+	}
+	fsys.cache[pointer.Oid] = data
+	return
+}
+
+// read the object from the local LFS object store
+func (fsys *LFSFS) fetch_local(pointer LFSPointer) (data []byte, err error) {
+	if fsys.Store == "" {
+		err = os.ErrNotExist
+		return
+	}
+
+	path := filepath.Join(fsys.Store, pointer.Oid[0:2], pointer.Oid[2:4], pointer.Oid)
+	data, err = os.ReadFile(path)
+	return
+}
+
+// download the object via the git LFS batch API
+func (fsys *LFSFS) fetch_remote(pointer LFSPointer) (data []byte, err error) {
+	request := struct {
+		Operation string       `json:"operation"`
+		Transfers []string     `json:"transfers"`
+		Objects   []LFSPointer `json:"objects"`
+	}{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   []LFSPointer{pointer},
+	}
+
+	var body []byte
+	if body, err = json.Marshal(request); err != nil {
+		// cannot marshal the batch request
+		return
+	}
+
+	endpoint := strings.TrimSuffix(fsys.Endpoint, "/") + "/objects/batch"
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body)); err != nil {
+		// cannot create request
+		return
+	}
+	req.Header.Set("Accept", LFS_MEDIA_TYPE)
+	req.Header.Set("Content-Type", LFS_MEDIA_TYPE)
+	if auth, ok := fsys.Auth.(interface{ SetAuth(*http.Request) }); ok {
+		auth.SetAuth(req)
+	}
+
+	var batch struct {
+		Objects []struct {
+			LFSPointer
+			Actions struct {
+				Download *struct {
+					Href   string            `json:"href"`
+					Header map[string]string `json:"header"`
+				} `json:"download"`
+			} `json:"actions"`
+			Error *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"objects"`
+	}
+	if err = fsys.do(req, func(reader io.Reader) error {
+		return json.NewDecoder(reader).Decode(&batch)
+	}); err != nil {
+		// cannot call the batch API
+		return
+	}
+
+	for _, object := range batch.Objects {
+		switch {
+		case object.Oid != pointer.Oid:
+		case object.Error != nil:
+			err = fmt.Errorf("git LFS object %v: %v", pointer.Oid, object.Error.Message)
+			return
+		case object.Actions.Download == nil:
+			err = fmt.Errorf("git LFS object %v: no download action", pointer.Oid)
+			return
+		default:
+			download := object.Actions.Download
+			if req, err = http.NewRequest(http.MethodGet, download.Href, nil); err != nil {
+				// cannot create request
+				return
+			}
+			for key, value := range download.Header {
+				req.Header.Set(key, value)
+			}
+
+			err = fsys.do(req, func(reader io.Reader) (err error) {
+				data, err = io.ReadAll(reader)
+				return
+			})
+			return
+		}
+	}
+
+	err = fmt.Errorf("git LFS object %v not found", pointer.Oid)
+	return
+}
+
+// send the HTTP request and process the response body
+func (fsys *LFSFS) do(req *http.Request, fn func(io.Reader) error) (err error) {
+	client := fsys.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		// cannot send the request
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("git LFS %v %v: %v", req.Method, req.URL.Redacted(), resp.Status)
+		return
+	}
+
+	err = fn(resp.Body)
+	return
+}
+
+// the resolved git LFS file
+type lfsFile struct {
+	fs.FileInfo
+	size int64
+	*bytes.Reader
+}
+
+func (file *lfsFile) Stat() (fs.FileInfo, error) {
+	return file, nil
+}
+
+func (file *lfsFile) Size() int64 {
+	return file.size
+}
+
+func (file *lfsFile) Close() error {
+	return nil
+}
@@ -0,0 +1,153 @@
+package clone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func mock_lfs_pointer(content []byte) (pointer LFSPointer, text string) {
+	sum := sha256.Sum256(content)
+
+	pointer = LFSPointer{Oid: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	text = fmt.Sprintf("%v\noid sha256:%v\nsize %v\n", LFS_POINTER_VERSION, pointer.Oid, pointer.Size)
+	return
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	expect, text := mock_lfs_pointer([]byte("the large image"))
+
+	if pointer, ok := ParseLFSPointer([]byte(text)); !ok || pointer != expect {
+		t.Errorf("expect pointer %v: %v", expect, pointer)
+	}
+
+	if _, ok := ParseLFSPointer([]byte("# The mock markdown post #\n")); ok {
+		t.Errorf("expect not the git LFS pointer")
+	}
+}
+
+func TestLFSFSRemote(t *testing.T) {
+	content := []byte("the large image from LFS server")
+	pointer, text := mock_lfs_pointer(content)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog.git/info/lfs/objects/batch":
+			if r.Header.Get("Accept") != LFS_MEDIA_TYPE {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+
+			json.NewEncoder(w).Encode(map[string]interface{}{ // nolint
+				"objects": []interface{}{
+					map[string]interface{}{
+						"oid":  pointer.Oid,
+						"size": pointer.Size,
+						"actions": map[string]interface{}{
+							"download": map[string]interface{}{
+								"href":   server.URL + "/objects/" + pointer.Oid,
+								"header": map[string]string{"X-Token": "mock"},
+							},
+						},
+					},
+				},
+			})
+		case "/objects/" + pointer.Oid:
+			if r.Header.Get("X-Token") != "mock" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(content) // nolint
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fsys := &LFSFS{
+		FS: fstest.MapFS{
+			"assets/image.png": {Data: []byte(text)},
+			"posts/example.md": {Data: []byte("# The mock markdown post #\n")},
+		},
+		Endpoint: server.URL + "/blog.git/info/lfs",
+	}
+
+	data, err := fs.ReadFile(fsys, "assets/image.png")
+	if err != nil {
+		t.Fatalf("cannot read the git LFS object: %v", err)
+	}
+	if string(data) != string(content) {
+		t.Errorf("expect resolved content %q: %q", content, data)
+	}
+
+	if data, _ := fs.ReadFile(fsys, "posts/example.md"); string(data) != "# The mock markdown post #\n" {
+		t.Errorf("expect the plain file as-is: %q", data)
+	}
+}
+
+func TestLFSFSLocal(t *testing.T) {
+	content := []byte("the large image from local store")
+	pointer, text := mock_lfs_pointer(content)
+
+	store := t.TempDir()
+	path := filepath.Join(store, pointer.Oid[0:2], pointer.Oid[2:4], pointer.Oid)
+	os.MkdirAll(filepath.Dir(path), 0750) // nolint
+	os.WriteFile(path, content, 0640)     // nolint
+
+	fsys := &LFSFS{
+		FS:    fstest.MapFS{"image.png": {Data: []byte(text)}},
+		Store: store,
+	}
+
+	if data, err := fs.ReadFile(fsys, "image.png"); err != nil || string(data) != string(content) {
+		t.Errorf("expect resolved content %q: %q %v", content, data, err)
+	}
+
+	if info, err := fs.Stat(fsys, "image.png"); err != nil || info.Size() != int64(len(content)) {
+		t.Errorf("expect resolved size %v: %v", len(content), info)
+	}
+}
+
+type broken_stat_fs struct {
+	closed bool
+}
+
+type broken_stat_file struct {
+	fs.File
+	fsys *broken_stat_fs
+}
+
+func (fsys *broken_stat_fs) Open(name string) (fs.File, error) {
+	return &broken_stat_file{fsys: fsys}, nil
+}
+
+func (file *broken_stat_file) Stat() (fs.FileInfo, error) {
+	return nil, fs.ErrInvalid
+}
+
+func (file *broken_stat_file) Close() error {
+	file.fsys.closed = true
+	return nil
+}
+
+func TestLFSFSStatError(t *testing.T) {
+	broken := &broken_stat_fs{}
+	fsys := &LFSFS{FS: broken}
+
+	file, err := fsys.Open("image.png")
+	if err == nil || file != nil {
+		t.Errorf("expect the error without the file: %v %v", file, err)
+	}
+	if !broken.closed {
+		t.Errorf("expect the file closed when cannot stat")
+	}
+}
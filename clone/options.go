@@ -0,0 +1,40 @@
+package clone
+
+// the options of cloning the repository, shared by the commands
+type Options struct {
+	// Auth with username/password or the bearer token, prefer to set by the
+	// environment variables which never leak into the process listing
+	Username         string `short:"U" env:"GITUP_USERNAME" help:"the username used for auth"`
+	Password         string `short:"P" env:"GITUP_PASSWORD" help:"the password used for auth"`
+	Token            string `env:"GITUP_TOKEN" help:"the bearer token used for auth"`
+	Netrc            bool   `negatable:"" default:"true" help:"read the credential from the netrc file"`
+	CredentialHelper bool   `name:"credential-helper" help:"read the credential from the git credential helper"`
+
+	// remove the temporary folder
+	Purge bool `short:"p" negatable:"" default:"true" help:"purge the temporary repo cloned from remote"`
+
+	// the shallow and sparse clone options
+	Depth        int    `help:"create a shallow clone with history truncated to the specified number of commits"`
+	Branch       string `short:"b" help:"the branch to clone instead of the remote HEAD"`
+	SingleBranch bool   `name:"single-branch" help:"only fetch the history of the single branch"`
+	Sparse       bool   `help:"only checkout the config, the workdir and the referenced files"`
+
+	// read from the git object store without checkout
+	Memory bool `name:"in-memory" help:"clone into memory and read files from the git objects without checkout"`
+
+	// the submodule and git LFS support
+	Submodules bool   `help:"recurse the submodules"`
+	LFS        bool   `name:"lfs" help:"resolve the git LFS pointers to the real content"`
+	LFSStore   string `name:"lfs-store" type:"path" help:"the local git LFS object store (default: lfs/objects in the git folder)"`
+	LFSURL     string `name:"lfs-url" help:"the git LFS server endpoint (default: derived from the remote)"`
+
+	// run the external converters in the config of the repository
+	Converters bool `help:"allow the external converter commands of the render.formats in the config"`
+
+	// write the pre-compressed siblings for the static servers
+	Compress          []string `enum:"gzip,br" help:"write the pre-compressed siblings of the text outputs, gzip or br"`
+	CompressThreshold int      `name:"compress-threshold" default:"1024" help:"the minimum size in bytes of the text output to compress"`
+
+	// the manifest of the generated pages to the sources, used by check links
+	Manifest string `type:"path" help:"write the manifest of the generated pages to the blog/markdown sources, keep it outside the output"`
+}
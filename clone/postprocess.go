@@ -0,0 +1,350 @@
+package clone
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cmj0121/gitup/config"
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	minify_json "github.com/tdewolff/minify/v2/json"
+	"github.com/tdewolff/minify/v2/svg"
+	"github.com/tdewolff/minify/v2/xml"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the manifest of the fingerprinted assets, the original to the
+	// fingerprinted path
+	ASSETS_MANIFEST = "assets-manifest.json"
+)
+
+var (
+	// the media type of the minified outputs by the extension
+	MINIFY_TYPES = map[string]string{
+		".htm":  "text/html",
+		".html": "text/html",
+		".css":  "text/css",
+		".js":   "application/javascript",
+		".json": "application/json",
+		".xml":  "text/xml",
+		".svg":  "image/svg+xml",
+	}
+
+	// the static assets can be fingerprinted, the referenced files like the
+	// stylesheet, script, image and font
+	FINGERPRINT_EXTS = []string{
+		".css", ".js", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico",
+		".woff", ".woff2", ".ttf", ".otf", ".eot", ".mp4", ".webm",
+	}
+
+	// the references in the HTML attributes and the CSS
+	RE_REF_ATTR   = regexp.MustCompile(`(?i)(\s(?:src|href|poster|data-src)\s*=\s*)("[^"]*"|'[^']*')`)
+	RE_REF_SRCSET = regexp.MustCompile(`(?i)(\ssrcset\s*=\s*)("[^"]*"|'[^']*')`)
+	RE_REF_URL    = regexp.MustCompile(`(url\(\s*)("[^"]*"|'[^']*'|[^'")\s]+)(\s*\))`)
+)
+
+// the optional post-processing stage after all the pages are written,
+// fingerprint the static assets, rewrite the references and minify
+func (clone *Clone) postprocess(conf *config.Config) (err error) {
+	if !conf.Minify && !conf.Fingerprint {
+		// nothing to do
+		return
+	}
+
+	var files []string
+	err = filepath.WalkDir(clone.Output, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			rel, _ := filepath.Rel(clone.Output, path) // nolint
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		// cannot list the outputs
+		return
+	}
+
+	// the outputs already fingerprinted by the build, the stylesheet of the
+	// site is written with the content fingerprint and minified
+	fingerprinted := map[string]struct{}{}
+	if link := conf.StyleLink(); link != "" {
+		fingerprinted[link] = struct{}{}
+	}
+
+	m := new_minifier()
+	if conf.Minify {
+		// minify the assets before the fingerprint, the pages are minified
+		// after the references rewritten, and the fingerprinted one is final
+		for _, file := range files {
+			if _, ok := fingerprinted[file]; !ok && !is_page(file) {
+				clone.minify(m, file)
+			}
+		}
+	}
+
+	if conf.Fingerprint {
+		var mapping map[string]string
+		if mapping, err = clone.fingerprint(files, fingerprinted); err != nil {
+			// cannot fingerprint the assets
+			return
+		}
+
+		for idx, file := range files {
+			if renamed, ok := mapping[file]; ok {
+				files[idx] = renamed
+			}
+		}
+	}
+
+	if conf.Minify {
+		for _, file := range files {
+			if is_page(file) {
+				clone.minify(m, file)
+			}
+		}
+	}
+
+	return
+}
+
+// fingerprint the static assets and rewrite the references, the leaf assets
+// first and then the stylesheets which may reference them, the fingerprinted
+// outputs are never renamed again
+func (clone *Clone) fingerprint(files []string, fingerprinted map[string]struct{}) (mapping map[string]string, err error) {
+	mapping = map[string]string{}
+
+	var leaves, sheets, pages []string
+	for _, file := range files {
+		ext := strings.ToLower(path.Ext(file))
+		if _, ok := fingerprinted[file]; ok {
+			// already fingerprinted, like the stylesheet of the site
+			continue
+		}
+
+		switch {
+		case is_page(file):
+			pages = append(pages, file)
+		case !is_fingerprintable(file):
+			// keep the name, like the CNAME and robots.txt
+		case ext == ".css":
+			sheets = append(sheets, file)
+		default:
+			leaves = append(leaves, file)
+		}
+	}
+
+	for _, group := range [][]string{leaves, sheets} {
+		for _, file := range group {
+			if path.Ext(file) == ".css" {
+				if err = clone.rewrite(file, mapping); err != nil {
+					return
+				}
+			}
+
+			if err = clone.rename(file, mapping); err != nil {
+				return
+			}
+		}
+	}
+
+	for _, page := range pages {
+		if err = clone.rewrite(page, mapping); err != nil {
+			return
+		}
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(mapping, "", "  "); err != nil {
+		// cannot generate the manifest
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(clone.Output, ASSETS_MANIFEST), data, 0640)
+	return
+}
+
+// rename the asset with the content fingerprint
+func (clone *Clone) rename(file string, mapping map[string]string) (err error) {
+	src := filepath.Join(clone.Output, filepath.FromSlash(file))
+
+	var data []byte
+	if data, err = os.ReadFile(src); err != nil {
+		// cannot read the asset
+		return
+	}
+
+	ext := path.Ext(file)
+	renamed := strings.TrimSuffix(file, ext) + "." + config.Fingerprint(data) + ext
+	if err = os.Rename(src, filepath.Join(clone.Output, filepath.FromSlash(renamed))); err != nil {
+		// cannot rename the asset
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"path":    file,
+		"renamed": renamed,
+	}).Trace("fingerprint the asset")
+
+	mapping[file] = renamed
+	return
+}
+
+// rewrite the references to the fingerprinted assets
+func (clone *Clone) rewrite(file string, mapping map[string]string) (err error) {
+	dest := filepath.Join(clone.Output, filepath.FromSlash(file))
+
+	var data []byte
+	if data, err = os.ReadFile(dest); err != nil {
+		// cannot read the file
+		return
+	}
+
+	dir := path.Dir(file)
+	text := RE_REF_ATTR.ReplaceAllStringFunc(string(data), func(matched string) string {
+		parts := RE_REF_ATTR.FindStringSubmatch(matched)
+		quote := parts[2][:1]
+		return parts[1] + quote + rewrite_ref(dir, parts[2][1:len(parts[2])-1], mapping) + quote
+	})
+	text = RE_REF_SRCSET.ReplaceAllStringFunc(text, func(matched string) string {
+		parts := RE_REF_SRCSET.FindStringSubmatch(matched)
+		quote := parts[2][:1]
+
+		var candidates []string
+		for _, candidate := range strings.Split(parts[2][1:len(parts[2])-1], ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) > 0 {
+				fields[0] = rewrite_ref(dir, fields[0], mapping)
+			}
+			candidates = append(candidates, strings.Join(fields, " "))
+		}
+		return parts[1] + quote + strings.Join(candidates, ", ") + quote
+	})
+	text = RE_REF_URL.ReplaceAllStringFunc(text, func(matched string) string {
+		parts := RE_REF_URL.FindStringSubmatch(matched)
+		ref, quote := parts[2], ""
+		if ref[0] == '"' || ref[0] == '\'' {
+			ref, quote = ref[1:len(ref)-1], ref[:1]
+		}
+		return parts[1] + quote + rewrite_ref(dir, ref, mapping) + quote + parts[3]
+	})
+
+	if text != string(data) {
+		err = os.WriteFile(dest, []byte(text), 0640)
+	}
+	return
+}
+
+// rewrite the single reference related to the folder, keep the query and the
+// fragment
+func rewrite_ref(dir, ref string, mapping map[string]string) (rewritten string) {
+	rewritten = ref
+
+	link, suffix := ref, ""
+	if idx := strings.IndexAny(ref, "?#"); idx >= 0 {
+		link, suffix = ref[:idx], ref[idx:]
+	}
+
+	switch {
+	case link == "", strings.Contains(link, ":"), strings.HasPrefix(link, "//"):
+		// the external link, the data URI or the fragment only
+		return
+	}
+
+	target := path.Join(dir, link)
+	if strings.HasPrefix(link, "/") {
+		target = strings.TrimPrefix(path.Clean(link), "/")
+	}
+
+	if renamed, ok := mapping[target]; ok {
+		rewritten = path.Join(path.Dir(link), path.Base(renamed)) + suffix
+	}
+
+	return
+}
+
+// minify the output file in place, keep the original if cannot minify
+func (clone *Clone) minify(m *minify.M, file string) {
+	media, ok := MINIFY_TYPES[strings.ToLower(path.Ext(file))]
+	if !ok {
+		// not the text output
+		return
+	}
+
+	dest := filepath.Join(clone.Output, filepath.FromSlash(file))
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  file,
+			"error": err,
+		}).Warn("cannot read the output")
+		return
+	}
+
+	var buff bytes.Buffer
+	if err := m.Minify(media, &buff, bytes.NewReader(data)); err != nil {
+		log.WithFields(log.Fields{
+			"path":  file,
+			"error": err,
+		}).Info("cannot minify the output")
+		return
+	}
+
+	if err := os.WriteFile(dest, buff.Bytes(), 0640); err != nil {
+		log.WithFields(log.Fields{
+			"path":  file,
+			"error": err,
+		}).Warn("cannot write the minified output")
+	}
+}
+
+// the minifier of the HTML, CSS, JS, JSON, XML and SVG
+func new_minifier() (m *minify.M) {
+	m = minify.New()
+	m.AddFunc("text/css", css.Minify)
+	m.Add("text/html", &html.Minifier{
+		KeepDocumentTags: true,
+		KeepEndTags:      true,
+		KeepQuotes:       true,
+	})
+	m.AddFunc("image/svg+xml", svg.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`^(application|text)/(x-)?(java|ecma)script$`), js.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`[/+]json$`), minify_json.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`[/+]xml$`), xml.Minify)
+	return
+}
+
+// the generated HTML page, never fingerprinted
+func is_page(file string) (page bool) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".htm", ".html":
+		page = true
+	}
+	return
+}
+
+// the asset can be fingerprinted by the extension
+func is_fingerprintable(file string) (ok bool) {
+	if file == ASSETS_MANIFEST {
+		// the manifest is always kept as-is
+		return
+	}
+
+	ext := strings.ToLower(path.Ext(file))
+	for _, fingerprint_ext := range FINGERPRINT_EXTS {
+		if ext == fingerprint_ext {
+			ok = true
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package clone
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestPostprocess(t *testing.T) {
+	output := t.TempDir()
+	files := map[string]string{
+		"index.htm":          "<html>\n  <head>\n    <link rel=\"stylesheet\" href=\"css/site.css?v=1\" />\n  </head>\n  <body>\n    <img src='img/logo.png' srcset=\"img/logo.png 1x, /img/logo.png 2x\" />\n    <a href=\"post-list.htm\">list</a>\n    <a href=\"https://example.com/img/logo.png\">external</a>\n  </body>\n</html>\n",
+		"css/site.css":       "body {\n  background: url(\"../img/logo.png\");\n}\n",
+		"img/logo.png":       "the PNG image",
+		"post-list.htm":      "<html></html>",
+		"CNAME":              "blog.example.com",
+		"data/feed.json":     "{\n  \"title\": \"feed\"\n}\n",
+		"js/lib.0123abcd.js": "var  lib = 1;\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0750)  // nolint
+		os.WriteFile(path, []byte(text), 0640) // nolint
+	}
+
+	conf := &config.Config{}
+	conf.Minify = true
+	conf.Fingerprint = true
+
+	if err := conf.WriteStyle(output); err != nil || conf.StyleLink() == "" {
+		t.Fatalf("cannot write the stylesheet: %v", err)
+	}
+
+	clone := &Clone{Output: output}
+	if err := clone.postprocess(conf); err != nil {
+		t.Fatalf("cannot post-process: %v", err)
+	}
+
+	logo := "img/logo." + config.Fingerprint([]byte("the PNG image")) + ".png"
+	if _, err := os.Stat(filepath.Join(output, logo)); err != nil {
+		t.Fatalf("expect the fingerprinted asset: %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(output, "css", "site.*.css")) // nolint
+	if len(matches) != 1 {
+		t.Fatalf("expect the fingerprinted stylesheet: %v", matches)
+	}
+	sheet, _ := os.ReadFile(matches[0]) // nolint
+	if string(sheet) != "body{background:url(../"+logo+")}" {
+		t.Errorf("invalid stylesheet: %s", sheet)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(output, "index.htm")) // nolint
+	for _, expect := range []string{
+		`href="css/` + filepath.Base(matches[0]) + `?v=1"`,
+		`src='` + logo + `'`,
+		logo + ` 1x, /` + logo + ` 2x`,
+		`href="post-list.htm"`,
+		`href="https://example.com/img/logo.png"`,
+	} {
+		if !strings.Contains(string(index), expect) {
+			t.Errorf("expect %v in the page: %s", expect, index)
+		}
+	}
+	if strings.Contains(string(index), "\n  ") {
+		t.Errorf("expect the minified page: %s", index)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(output, "data", "feed.json")); string(data) != `{"title":"feed"}` { // nolint
+		t.Errorf("expect the minified JSON: %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(output, conf.StyleLink())); err != nil {
+		t.Errorf("expect the stylesheet of the site kept: %v", err)
+	}
+	lib := "js/lib.0123abcd." + config.Fingerprint([]byte("var lib=1")) + ".js"
+	if data, err := os.ReadFile(filepath.Join(output, lib)); err != nil || string(data) != "var lib=1" {
+		t.Errorf("expect the asset like the fingerprinted name fingerprinted: %s, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "CNAME")); err != nil {
+		t.Errorf("expect the CNAME kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, ASSETS_MANIFEST)); err != nil {
+		t.Errorf("expect the assets manifest: %v", err)
+	}
+}
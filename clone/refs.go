@@ -0,0 +1,125 @@
+package clone
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the index of the blog/markdown sources to the generated output links
+type references struct {
+	paths map[string]string            // the source path to the output link
+	names map[string][]string          // the source name without the extension to the output links
+	links map[string]map[string]string // the source path to the resolved links and the original ones
+}
+
+// build the index after all the output links are decided, the blogs and the
+// standalone pages
+func (clone *Clone) references(conf *config.Config) (refs *references) {
+	refs = &references{
+		paths: map[string]string{},
+		names: map[string][]string{},
+		links: map[string]map[string]string{},
+	}
+
+	for _, blog := range clone.blogs {
+		refs.add(blog.Path, blog.Link)
+	}
+	for _, page := range conf.NavPages() {
+		if src, err := clone.resolve(page.Source); err == nil {
+			refs.add(src, page.Link())
+		}
+	}
+
+	return
+}
+
+// add the source path and the output link
+func (refs *references) add(source, link string) {
+	if _, ok := refs.paths[source]; ok {
+		// the first one wins, like the aggregated sources with the same path
+		return
+	}
+	refs.paths[source] = link
+
+	name := strings.TrimSuffix(path.Base(source), path.Ext(source))
+	refs.names[name] = append(refs.names[name], link)
+}
+
+// resolve the link in the source into the output link, the ref: shortcut can
+// be the source path or the unique name without the extension
+func (refs *references) resolve(source, link string) (resolved string, ok bool) {
+	target, suffix := link, ""
+	if idx := strings.IndexAny(link, "?#"); idx >= 0 {
+		target, suffix = link[:idx], link[idx:]
+	}
+
+	candidates := []string{}
+	switch {
+	case strings.HasPrefix(target, blog.REF_PREFIX):
+		target = strings.TrimPrefix(target, blog.REF_PREFIX)
+		candidates = append(candidates, strings.TrimPrefix(path.Clean("/"+target), "/"))
+		candidates = append(candidates, path.Join(path.Dir(source), target))
+	case strings.HasPrefix(target, "/"):
+		candidates = append(candidates, strings.TrimPrefix(path.Clean(target), "/"))
+	default:
+		candidates = append(candidates, path.Join(path.Dir(source), target))
+	}
+
+	for _, candidate := range candidates {
+		if resolved, ok = refs.paths[candidate]; ok {
+			resolved += suffix
+			return
+		}
+	}
+
+	if strings.HasPrefix(link, blog.REF_PREFIX) && path.Ext(target) == "" {
+		// the shortcut by the unique name
+		if links := refs.names[path.Base(target)]; len(links) == 1 {
+			resolved, ok = links[0]+suffix, true
+		}
+	}
+
+	return
+}
+
+// resolve the links to the other blog/markdown sources, the unresolved link
+// fails in the strict mode, and keep the original links of the resolved ones
+func (refs *references) resolve_blog(conf *config.Config, md_blog *blog.Blog) (err error) {
+	resolved := map[string]string{}
+	resolver := func(source, link string) (output string, ok bool) {
+		if output, ok = refs.resolve(source, link); ok {
+			resolved[output] = link
+		}
+		return
+	}
+
+	var unresolved []string
+	if unresolved, err = md_blog.Resolve(resolver); err != nil {
+		// cannot render the blog
+		return
+	}
+
+	if len(resolved) > 0 {
+		refs.links[md_blog.Path] = resolved
+	}
+
+	for _, link := range unresolved {
+		log.WithFields(log.Fields{
+			"path": md_blog.Path,
+			"link": link,
+		}).Warn("cannot resolve the reference")
+	}
+
+	if len(unresolved) > 0 && conf.Strict {
+		err = fmt.Errorf("%v: unresolved reference %v", md_blog.Path, strings.Join(unresolved, ", "))
+		return
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package clone
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestReferences(t *testing.T) {
+	posts := map[string]string{
+		"posts/2022/foo.md": "# Foo\n\nsee [bar](../2023/bar.md#intro) and [me](ref:me.md)\n",
+		"posts/2023/bar.md": "# Bar\n\nsee [foo](ref:foo) and [root](/posts/2022/foo.md)\n",
+		"posts/baz.md":      "# Baz\n\nsee [missing](missing.md) and [external](https://example.com/README.md)\n",
+	}
+
+	generate := func(strict bool) (clone *Clone, err error) {
+		clone = &Clone{
+			Output:  t.TempDir(),
+			Options: Options{Manifest: filepath.Join(t.TempDir(), "sources.json")},
+			fsys: fstest.MapFS{
+				"me.md": &fstest.MapFile{Data: []byte("# About Me\n")},
+			},
+		}
+
+		now := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+		for path, text := range posts {
+			md_blog, err := blog.New(strings.NewReader(text))
+			if err != nil {
+				t.Fatalf("cannot create blog: %v", err)
+			}
+
+			md_blog.Path = path
+			md_blog.CreatedAt = now
+			md_blog.UpdatedAt = now
+			clone.blogs = append(clone.blogs, md_blog)
+		}
+
+		conf := &config.Config{}
+		conf.DisabledTimestampPrefix = true
+		conf.AboutMe = "me.md"
+		conf.Strict = strict
+		err = clone.Generate(conf)
+		return
+	}
+
+	clone, err := generate(false)
+	if err != nil {
+		t.Fatalf("cannot generate: %v", err)
+	}
+
+	expect := map[string][]string{
+		"foo.htm": {`href="bar.htm#intro"`, `href="about-me.htm"`},
+		"bar.htm": {`href="foo.htm"`, `href="foo.htm"`},
+		"baz.htm": {`href="missing.md"`, `href="https://example.com/README.md"`},
+	}
+	for name, links := range expect {
+		text, err := os.ReadFile(clone.Output + "/" + name)
+		if err != nil {
+			t.Fatalf("expect %v generated: %v", name, err)
+		}
+
+		for _, link := range links {
+			if !strings.Contains(string(text), link) {
+				t.Errorf("expect %v in %v", link, name)
+			}
+		}
+	}
+
+	var manifest map[string]ManifestPage
+	data, _ := os.ReadFile(clone.Manifest) // nolint
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if page := manifest["foo.htm"]; page.Source != "posts/2022/foo.md" || page.Links["bar.htm#intro"] != "../2023/bar.md#intro" || page.Links["about-me.htm"] != "ref:me.md" {
+		t.Errorf("expect the original links in the manifest: %+v", page)
+	}
+
+	if _, err := generate(true); err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Errorf("expect the unresolved reference fails in strict mode: %v", err)
+	}
+}
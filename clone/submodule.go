@@ -0,0 +1,156 @@
+package clone
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// update the submodules in the working space
+func (clone *Clone) update_submodules(repo *git.Repository) (err error) {
+	var worktree *git.Worktree
+	if worktree, err = repo.Worktree(); err != nil {
+		// cannot get the worktree
+		return
+	}
+
+	var submodules git.Submodules
+	if submodules, err = worktree.Submodules(); err != nil {
+		// cannot list the submodules
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"count": len(submodules),
+	}).Info("update the submodules")
+
+	options := git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		Auth:              clone.credential,
+	}
+	err = submodules.Update(&options)
+	return
+}
+
+// clone the submodules into memory and mount into the git tree file system
+func (clone *Clone) mount_submodules(fsys *TreeFS, base string, depth int) (err error) {
+	var data []byte
+	if data, err = fs.ReadFile(fsys, ".gitmodules"); err != nil {
+		// no submodule
+		err = nil
+		return
+	}
+
+	modules := gitconfig.NewModules()
+	if err = modules.Unmarshal(data); err != nil {
+		// invalid .gitmodules
+		return
+	}
+
+	for name, submodule := range modules.Submodules {
+		entry, ferr := fsys.tree.FindEntry(submodule.Path)
+		if ferr != nil || entry.Mode != filemode.Submodule {
+			log.WithFields(log.Fields{
+				"submodule": name,
+				"path":      submodule.Path,
+			}).Warn("submodule not found in the tree")
+			continue
+		}
+
+		remote := submodule_url(base, submodule.URL)
+		log.WithFields(log.Fields{
+			"submodule": name,
+			"path":      submodule.Path,
+			"commit":    entry.Hash,
+		}).Info("clone the submodule into memory")
+
+		options := git.CloneOptions{
+			URL:  remote,
+			Auth: clone.credential,
+		}
+
+		var repo *git.Repository
+		if repo, err = git.Clone(memory.NewStorage(), nil, &options); err != nil {
+			err = fmt.Errorf("cannot clone submodule %v: %v", name, err)
+			return
+		}
+
+		var commit *object.Commit
+		if commit, err = repo.CommitObject(entry.Hash); err != nil {
+			err = fmt.Errorf("cannot find submodule %v commit %v: %v", name, entry.Hash, err)
+			return
+		}
+
+		var sub *TreeFS
+		if sub, err = NewTreeFS(commit); err != nil {
+			// cannot read the submodule tree
+			return
+		}
+
+		if depth > 1 {
+			if err = clone.mount_submodules(sub, remote, depth-1); err != nil {
+				// cannot mount the nested submodules
+				return
+			}
+		}
+
+		fsys.Mount(submodule.Path, sub)
+	}
+
+	return
+}
+
+// resolve the submodule URL, which may be related to the super-project
+func submodule_url(base, remote string) (resolved string) {
+	resolved = remote
+	if !strings.HasPrefix(remote, "./") && !strings.HasPrefix(remote, "../") {
+		// the absolute URL
+		return
+	}
+
+	switch uri, err := url.Parse(base); {
+	case err == nil && uri.Scheme != "" && uri.Scheme != "file":
+		uri.Path = path.Join(uri.Path, remote)
+		resolved = uri.String()
+	default:
+		base = strings.TrimPrefix(base, "file://")
+		resolved = filepath.Join(base, filepath.FromSlash(remote))
+	}
+
+	return
+}
+
+// the git folder of the local repository, empty if in memory
+func (clone *Clone) gitdir() (dir string) {
+	switch {
+	case clone.local == "":
+		// clone from remote
+		if clone.tempdir != "" {
+			dir = filepath.Join(clone.tempdir, ".git")
+		}
+	case clone.tempdir == "":
+		// the bare repository
+		dir = clone.local
+	default:
+		dir = filepath.Join(clone.local, ".git")
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		// not found or the git file of the worktree
+		dir = ""
+	}
+	return
+}
@@ -0,0 +1,263 @@
+package clone
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// the read-only file system backed by the git tree, read the files from
+// the git object store directly without checkout
+type TreeFS struct {
+	tree    *object.Tree
+	modtime time.Time
+
+	// the mounted file system, like the submodules
+	mounts map[string]fs.FS
+}
+
+// create the file system from the git commit, the commit time is used as
+// the modified time of all files
+func NewTreeFS(commit *object.Commit) (fsys *TreeFS, err error) {
+	var tree *object.Tree
+	if tree, err = commit.Tree(); err != nil {
+		// cannot get the tree of the commit
+		return
+	}
+
+	fsys = &TreeFS{
+		tree:    tree,
+		modtime: commit.Committer.When,
+		mounts:  map[string]fs.FS{},
+	}
+	return
+}
+
+// mount the file system to the folder, like the submodule
+func (fsys *TreeFS) Mount(dir string, sub fs.FS) {
+	fsys.mounts[path.Clean(dir)] = sub
+}
+
+// open the file or the folder by the name
+func (fsys *TreeFS) Open(name string) (file fs.File, err error) {
+	if !fs.ValidPath(name) {
+		err = &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+		return
+	}
+
+	if name == "." {
+		file = &treeDir{info: fsys.info(".", filemode.Dir, 0), tree: fsys.tree, fsys: fsys, path: name}
+		return
+	}
+
+	for dir, sub := range fsys.mounts {
+		switch {
+		case name == dir:
+			file, err = sub.Open(".")
+			return
+		case strings.HasPrefix(name, dir+"/"):
+			file, err = sub.Open(name[len(dir)+1:])
+			return
+		}
+	}
+
+	var entry *object.TreeEntry
+	if entry, err = fsys.tree.FindEntry(name); err != nil {
+		err = &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		return
+	}
+
+	switch entry.Mode {
+	case filemode.Dir:
+		var tree *object.Tree
+		if tree, err = fsys.tree.Tree(name); err != nil {
+			err = &fs.PathError{Op: "open", Path: name, Err: err}
+			return
+		}
+
+		file = &treeDir{info: fsys.info(path.Base(name), entry.Mode, 0), tree: tree, fsys: fsys, path: name}
+	case filemode.Regular, filemode.Executable, filemode.Deprecated:
+		var blob *object.File
+		if blob, err = fsys.tree.TreeEntryFile(entry); err != nil {
+			err = &fs.PathError{Op: "open", Path: name, Err: err}
+			return
+		}
+
+		var text string
+		if text, err = blob.Contents(); err != nil {
+			err = &fs.PathError{Op: "open", Path: name, Err: err}
+			return
+		}
+
+		file = &treeFile{
+			info:   fsys.info(path.Base(name), entry.Mode, blob.Size),
+			Reader: bytes.NewReader([]byte(text)),
+		}
+	default:
+		// the symlink and the unmounted submodule are not supported
+		err = &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return
+}
+
+func (fsys *TreeFS) info(name string, mode filemode.FileMode, size int64) (info *treeInfo) {
+	info = &treeInfo{
+		name:    name,
+		mode:    mode,
+		size:    size,
+		modtime: fsys.modtime,
+	}
+	return
+}
+
+// the file in the git tree
+type treeFile struct {
+	info *treeInfo
+	*bytes.Reader
+}
+
+func (file *treeFile) Stat() (fs.FileInfo, error) {
+	return file.info, nil
+}
+
+func (file *treeFile) Close() error {
+	return nil
+}
+
+// the folder in the git tree
+type treeDir struct {
+	info   *treeInfo
+	tree   *object.Tree
+	fsys   *TreeFS
+	path   string
+	offset int
+}
+
+func (dir *treeDir) Stat() (fs.FileInfo, error) {
+	return dir.info, nil
+}
+
+func (dir *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: dir.info.name, Err: fs.ErrInvalid}
+}
+
+func (dir *treeDir) Close() error {
+	return nil
+}
+
+// list the entries of the folder, sorted by the name
+func (dir *treeDir) ReadDir(count int) (entries []fs.DirEntry, err error) {
+	all := make([]fs.DirEntry, 0, len(dir.tree.Entries))
+	for _, entry := range dir.tree.Entries {
+		mode := entry.Mode
+		if mode == filemode.Submodule {
+			if _, ok := dir.fsys.mounts[path.Join(dir.path, entry.Name)]; ok {
+				// the mounted submodule is shown as the folder
+				mode = filemode.Dir
+			}
+		}
+
+		all = append(all, &treeEntry{name: entry.Name, mode: mode, dir: dir})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+
+	all = all[dir.offset:]
+	switch {
+	case count <= 0:
+		entries = all
+	case len(all) == 0:
+		err = io.EOF
+	case count < len(all):
+		entries = all[:count]
+	default:
+		entries = all
+	}
+
+	dir.offset += len(entries)
+	return
+}
+
+// the entry of the folder, the size of the file is read from the blob only
+// when the info is required
+type treeEntry struct {
+	name string
+	mode filemode.FileMode
+	dir  *treeDir
+}
+
+func (entry *treeEntry) Name() string {
+	return entry.name
+}
+
+func (entry *treeEntry) IsDir() bool {
+	return entry.mode == filemode.Dir
+}
+
+func (entry *treeEntry) Type() fs.FileMode {
+	return (&treeInfo{mode: entry.mode}).Mode().Type()
+}
+
+func (entry *treeEntry) Info() (info fs.FileInfo, err error) {
+	var size int64
+	if entry.mode.IsFile() {
+		if size, err = entry.dir.tree.Size(entry.name); err != nil {
+			// cannot read the blob
+			return
+		}
+	}
+
+	info = entry.dir.fsys.info(entry.name, entry.mode, size)
+	return
+}
+
+// the file info of the git tree entry
+type treeInfo struct {
+	name    string
+	mode    filemode.FileMode
+	size    int64
+	modtime time.Time
+}
+
+func (info *treeInfo) Name() string {
+	return info.name
+}
+
+func (info *treeInfo) Size() int64 {
+	return info.size
+}
+
+func (info *treeInfo) Mode() (mode fs.FileMode) {
+	switch info.mode {
+	case filemode.Dir:
+		mode = fs.ModeDir | 0550
+	case filemode.Executable:
+		mode = 0550
+	case filemode.Symlink:
+		mode = fs.ModeSymlink | 0440
+	case filemode.Submodule:
+		mode = fs.ModeIrregular
+	default:
+		mode = 0440
+	}
+	return
+}
+
+func (info *treeInfo) ModTime() time.Time {
+	return info.modtime
+}
+
+func (info *treeInfo) IsDir() bool {
+	return info.mode == filemode.Dir
+}
+
+func (info *treeInfo) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,55 @@
+package clone
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestTreeFS(t *testing.T) {
+	worktree := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), worktree)
+	if err != nil {
+		t.Fatalf("cannot init repository: %v", err)
+	}
+
+	files := map[string]string{
+		".gitup.yml":         "workdir: [posts]\n",
+		"posts/example.md":   "# The mock markdown post #\n",
+		"posts/2022/next.md": "# The next post #\n",
+	}
+	for name, text := range files {
+		file, err := worktree.Create(name)
+		if err != nil {
+			t.Fatalf("cannot create %v: %v", name, err)
+		}
+		file.Write([]byte(text)) // nolint
+		file.Close()
+	}
+
+	wt, _ := repo.Worktree()
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatalf("cannot add files: %v", err)
+	}
+
+	signature := &object.Signature{Name: "gitup", When: time.Now()}
+	hash, err := wt.Commit("init", &git.CommitOptions{Author: signature})
+	if err != nil {
+		t.Fatalf("cannot commit: %v", err)
+	}
+
+	commit, _ := repo.CommitObject(hash)
+	fsys, err := NewTreeFS(commit)
+	if err != nil {
+		t.Fatalf("cannot create tree fs: %v", err)
+	}
+
+	if err := fstest.TestFS(fsys, ".gitup.yml", "posts/example.md", "posts/2022/next.md"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 
 	"gopkg.in/yaml.v2"
@@ -25,12 +27,43 @@ type Config struct {
 	Project string `yaml:",omitempty"`
 	Author  string `yaml:",omitempty"`
 
+	// the source repositories aggregated into one site
+	Sources []Source `yaml:",omitempty"`
+
 	Render
 	Settings
+
+	// the rules of the blog/markdown lint
+	Lint Lint `yaml:",omitempty"`
+
+	// the named profiles, like staging and production, overlay the config
+	// when chosen by the --profile
+	Profiles map[string]interface{} `yaml:",omitempty"`
+
+	origins        map[string]string      // where the config keys come from
+	overrides      []override             // the overrides from the environment and the command-line
+	profile        string                 // the active profile
+	profile_loaded bool                   // the active profile is found in any config
+	profiles       []profile_layer        // the profiles defined in the loaded configs
+	data           map[string]interface{} // the site data loaded from the data folder
+}
+
+// the source repository of the aggregated site
+type Source struct {
+	// the remote repository URI
+	Repo string
+
+	// the optional branch, workdir, output name prefix and author, override
+	// the settings of the source repository
+	Branch  string   `yaml:",omitempty"`
+	Workdir []string `yaml:",omitempty"`
+	Prefix  string   `yaml:",omitempty"`
+	Author  string   `yaml:",omitempty"`
 }
 
-// load the external config/configs and override the exists settings
-func (config *Config) Load(path string) {
+// load the external config/configs and override the exists settings, the
+// missing config is ignored
+func (config *Config) Load(path string) (err error) {
 	info, err := os.Stat(path)
 	switch {
 	case err == nil && info.IsDir():
@@ -50,49 +83,116 @@ func (config *Config) Load(path string) {
 				continue
 			}
 
-			config.Load(config_path)
+			if err = config.Load(config_path); err != nil {
+				// cannot load the config
+				return
+			}
 		}
 	case err == nil:
-		switch file, err := os.Open(path); err {
-		case nil:
-			defer file.Close()
-
-			log.WithFields(log.Fields{
-				"path": path,
-			}).Debug("load config from file")
-
-			config.LoadFromReader(file)
-		default:
+		var file *os.File
+		if file, err = os.Open(path); err != nil {
 			log.WithFields(log.Fields{
 				"path":  path,
 				"error": err,
 			}).Warn("cannot open config")
+			return
+		}
+		defer file.Close()
+
+		log.WithFields(log.Fields{
+			"path": path,
+		}).Debug("load config from file")
+
+		if err = config.load(file, path); err != nil {
+			err = fmt.Errorf("%v: %v", path, err)
 		}
 	default:
 		log.WithFields(log.Fields{
 			"path":  path,
 			"error": err,
 		}).Trace("cannot load config")
+
+		err = nil
 	}
+
+	return
 }
 
-// load the external config from io.Reader
-func (config *Config) LoadFromReader(reader io.Reader) {
+// load the config/configs from the folder of the file system, like the
+// repository read from the git object store
+func (config *Config) LoadFS(fsys fs.FS, dir string) (err error) {
+	for _, config_path := range ConfigPath {
+		config_path = path.Join(dir, config_path)
+
+		var file fs.File
+		if file, err = fsys.Open(config_path); err != nil {
+			log.WithFields(log.Fields{
+				"path":  config_path,
+				"error": err,
+			}).Trace("cannot load config")
+
+			err = nil
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"path": config_path,
+		}).Debug("load config from file")
+
+		err = config.load(file, config_path)
+		file.Close()
+
+		if err != nil {
+			err = fmt.Errorf("%v: %v", config_path, err)
+			return
+		}
+	}
+
+	return
+}
+
+// load the external config from io.Reader, the unknown key is treated as
+// the error with the line number
+func (config *Config) LoadFromReader(reader io.Reader) (err error) {
+	err = config.load(reader, "reader")
+	return
+}
+
+// load the config from io.Reader and record where the keys come from, the
+// overrides are always re-applied
+func (config *Config) load(reader io.Reader, origin string) (err error) {
 	var buff bytes.Buffer
 
-	if _, err := io.Copy(&buff, reader); err != nil {
+	if _, err = io.Copy(&buff, reader); err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Warn("cannot copy text from reader")
 		return
 	}
 
-	if err := yaml.Unmarshal(buff.Bytes(), &config); err != nil {
+	var node interface{}
+	if yaml.Unmarshal(buff.Bytes(), &node) == nil && node == nil {
+		// the empty document, nothing to override
+		return
+	}
+
+	if err = yaml.UnmarshalStrict(buff.Bytes(), config); err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Warn("cannot read config as YAML")
+
+		err = suggest(err)
 		return
 	}
+
+	config.recordYAML(buff.Bytes(), origin)
+	if err = config.overlay(buff.Bytes(), origin); err != nil {
+		// invalid profile
+		return
+	}
+
+	config.reapply()
+	return
 }
 
 // show the config as YAML format
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 var test_config = `
@@ -22,3 +24,238 @@ func TestLoadFromReader(t *testing.T) {
 	reader := strings.NewReader(test_config)
 	conf.LoadFromReader(reader)
 }
+
+func TestLoadFromReaderStrict(t *testing.T) {
+	conf := Config{}
+
+	reader := strings.NewReader("---\nsettings:\n  about_me: about-me.md\n")
+	err := conf.LoadFromReader(reader)
+	switch {
+	case err == nil:
+		t.Fatalf("expect the unknown key error")
+	case !strings.Contains(err.Error(), "line 3"):
+		t.Errorf("expect the line number: %v", err)
+	case !strings.Contains(err.Error(), "did you mean settings.abount_me?"):
+		t.Errorf("expect the suggestion: %v", err)
+	}
+
+	if err := conf.LoadFromReader(strings.NewReader("workdir: [\n")); err == nil {
+		t.Errorf("expect the invalid YAML error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/example.md": {Data: []byte("# The mock markdown post #\n")},
+		"about-me.md":      {Data: []byte("# About Me #\n")},
+		"blog.htm":         {Data: []byte("{{ .Blog.Title ")},
+	}
+
+	conf := Config{Workdir: []string{"posts"}}
+	conf.AboutMe = "about-me.md"
+	if err := conf.Validate(fsys); err != nil {
+		t.Fatalf("expect valid config: %v", err)
+	}
+
+	conf.Workdir = append(conf.Workdir, "drafts", "about-me.md")
+	conf.License = "../LICENSE"
+	conf.Html = "blog.htm"
+
+	err := conf.Validate(fsys)
+	errs, ok := err.(ValidationError)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("expect 4 problems: %v", err)
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	conf := Config{}
+	conf.Formats = map[string]Converter{
+		".adoc": {Command: []string{"asciidoctor", "-s", "-o", "-", "-"}},
+		".rst":  {Command: []string{"pandoc"}, Output: "pdf"},
+		"ipynb": {Command: []string{"jupyter"}},
+		".org":  {},
+	}
+
+	err := conf.Validate(fstest.MapFS{})
+	errs, ok := err.(ValidationError)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expect 3 problems: %v", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(SCHEMA), &schema); err != nil {
+		t.Fatalf("invalid JSON schema: %v", err)
+	}
+
+	for _, key := range Keys() {
+		node, ok := schema, true
+		for _, name := range strings.Split(key.Name, ".") {
+			properties, _ := node["properties"].(map[string]interface{})
+			if node, ok = properties[name].(map[string]interface{}); !ok {
+				t.Errorf("key %v not found in the JSON schema", key.Name)
+				break
+			}
+		}
+	}
+}
+
+func TestLayeredConfig(t *testing.T) {
+	conf := Config{}
+
+	environ := []string{"GITUP_RENDER_BRAND=env", "GITUP_TOKEN=ignored", "HOME=/"}
+	if err := conf.LoadEnv(environ); err != nil {
+		t.Fatalf("cannot load env: %v", err)
+	}
+	if err := conf.Set("workdir", "[posts, drafts]", "--set"); err != nil {
+		t.Fatalf("cannot set workdir: %v", err)
+	}
+
+	// the repository config is loaded later, but has the lower priority
+	text := "workdir: [blog]\nauthor: repo\nrender:\n  brand: repo\n"
+	if err := conf.load(strings.NewReader(text), ".gitup.yml"); err != nil {
+		t.Fatalf("cannot load config: %v", err)
+	}
+
+	expect := map[string]string{
+		"author":       ".gitup.yml",
+		"render.brand": "env GITUP_RENDER_BRAND",
+		"workdir":      "--set",
+		"project":      ORIGIN_DEFAULT,
+	}
+	for key, origin := range expect {
+		if conf.Origin(key) != origin {
+			t.Errorf("expect %v from %v: %v", key, origin, conf.Origin(key))
+		}
+	}
+
+	if conf.Brand != "env" || conf.Author != "repo" || len(conf.Workdir) != 2 {
+		t.Errorf("unexpected layered config: %v", conf)
+	}
+
+	if err := conf.Set("settings.unknown", "x", "--set"); err == nil {
+		t.Errorf("expect the unknown key error")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	text := `---
+render:
+  brand: Blog
+settings:
+  drafts: true
+profiles:
+  production:
+    settings:
+      base_url: https://blog.example.com/
+      robots: index, follow
+  broken:
+    settings:
+      about_me: about-me.md
+`
+
+	conf := Config{}
+	conf.UseProfile("production")
+	conf.Set("settings.robots", "noindex", "--set") // nolint
+	if err := conf.LoadFromReader(strings.NewReader(text)); err != nil {
+		t.Fatalf("cannot load the profile: %v", err)
+	}
+
+	switch {
+	case conf.CheckProfile() != nil:
+		t.Errorf("expect the profile found: %v", conf.CheckProfile())
+	case conf.Brand != "Blog" || !conf.Drafts:
+		t.Errorf("expect the base config kept: %v", conf)
+	case conf.BaseURL != "https://blog.example.com/":
+		t.Errorf("expect the profile overlay: %v", conf.BaseURL)
+	case conf.Robots != "noindex":
+		t.Errorf("expect the --set has the higher priority: %v", conf.Robots)
+	case conf.Origin("settings.base_url") != "profile production (reader)":
+		t.Errorf("invalid origin: %v", conf.Origin("settings.base_url"))
+	case conf.AbsURL("index.htm") != "https://blog.example.com/index.htm":
+		t.Errorf("invalid absolute URL: %v", conf.AbsURL("index.htm"))
+	}
+
+	conf = Config{}
+	conf.UseProfile("staging")
+	if err := conf.LoadFromReader(strings.NewReader(text)); err != nil {
+		t.Fatalf("cannot load the config: %v", err)
+	}
+	if err := conf.CheckProfile(); err == nil || !strings.Contains(err.Error(), "[broken production]") {
+		t.Errorf("expect the profile not found: %v", err)
+	}
+
+	conf = Config{}
+	conf.UseProfile("broken")
+	if err := conf.LoadFromReader(strings.NewReader(text)); err == nil || !strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expect the unknown key in the profile: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		".gitup.yml":  {Data: []byte(text)},
+		".gitup.yaml": {Data: []byte("profiles:\n  staging:\n    render:\n      brnad: Staging\n  nested:\n    profiles: {}\n")},
+	}
+	conf = Config{}
+	if err := conf.LoadFS(fsys, "."); err != nil {
+		t.Fatalf("cannot load the config without the active profile: %v", err)
+	}
+
+	err := conf.Validate(fsys)
+	for _, expect := range []string{
+		".gitup.yml: profile broken: ",
+		".gitup.yaml: profile staging: ",
+		".gitup.yaml: profile nested: cannot define the nested profiles",
+	} {
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("expect %v in the check: %v", expect, err)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), "production") {
+		t.Errorf("expect the valid profile passed: %v", err)
+	}
+}
+
+func TestPages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"about-me.md":              {Data: []byte("# About Me #\n")},
+		"pages/Projects List.md":   {Data: []byte("# Projects #\n")},
+		"pages/talks.md":           {Data: []byte("# Talks #\n")},
+		"layouts/page.htm":         {Data: []byte(`{{ .Blog.Title }}`)},
+		"layouts/partials/nav.htm": {Data: []byte(`{{ range .Config.NavPages }}{{ .Link }} {{ end }}`)},
+	}
+
+	conf := Config{}
+	conf.AboutMe = "about-me.md"
+	conf.Pages = []Page{
+		{Source: "pages/talks.md", Title: "Talks", Order: 2, Layout: "page.htm"},
+		{Source: "pages/Projects List.md", Title: "Projects", Order: 1},
+	}
+	conf.SetSource(fsys)
+
+	var links []string
+	for _, page := range conf.NavPages() {
+		links = append(links, page.Link())
+	}
+	if text := strings.Join(links, " "); text != "about-me.htm projects-list.htm talks.htm" {
+		t.Errorf("invalid navigation order: %v", text)
+	}
+
+	if err := conf.Validate(fsys); err != nil {
+		t.Fatalf("expect valid pages: %v", err)
+	}
+
+	conf.Pages = append(conf.Pages,
+		Page{Source: "pages/missing.md"},
+		Page{Source: "pages/talks.md", Output: "../talks.htm"},
+		Page{Source: "pages/talks.md", Output: "index.htm"},
+		Page{Source: "pages/talks.md", Output: "about-me.htm"},
+		Page{Source: "pages/talks.md", Output: "guide.htm", Layout: "missing.htm"},
+	)
+
+	err := conf.Validate(fsys)
+	if errs, ok := err.(ValidationError); !ok || len(errs) != 5 {
+		t.Errorf("expect 5 problems: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the folder of the site data files, like data/projects.yml
+	DATA_FOLDER = "data"
+)
+
+// load the YAML, JSON and CSV files in the data folder as the structured
+// value, keyed by the file name without the extension and nested by the
+// sub-folder, like data/talks/2022.yml as the .Data.talks.2022
+//
+// the data is replaced when loaded again, so the changed files can be
+// reloaded without rebuilding the config
+func (config *Config) LoadData() (err error) {
+	fsys := config.fsys()
+	data := map[string]interface{}{}
+
+	if _, err = fs.Stat(fsys, DATA_FOLDER); err != nil {
+		// no data folder
+		config.data = data
+		err = nil
+		return
+	}
+
+	// the key path to the data file or the sub-folder which defines it
+	defined := map[string]string{}
+	define := func(key, name string) (err error) {
+		if origin, ok := defined[key]; ok {
+			err = fmt.Errorf("%v: the data key %v is already defined by %v", name, strings.ReplaceAll(key, "/", "."), origin)
+			return
+		}
+
+		defined[key] = name
+		return
+	}
+
+	err = fs.WalkDir(fsys, DATA_FOLDER, func(name string, entry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case name == DATA_FOLDER:
+			return nil
+		case entry.Name()[0] == '.':
+			// skip the hidden file or folder
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		case entry.IsDir():
+			// the sub-folder is the nested key
+			return define(strings.TrimPrefix(name, DATA_FOLDER+"/"), name)
+		}
+
+		switch strings.ToLower(path.Ext(name)) {
+		case ".yml", ".yaml", ".json", ".csv":
+		default:
+			log.WithFields(log.Fields{
+				"path": name,
+			}).Info("skip the unsupported data file")
+			return nil
+		}
+
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			// cannot read the data file
+			return err
+		}
+
+		value, err := DecodeData(name, raw)
+		if err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+
+		if err := define(strings.TrimSuffix(strings.TrimPrefix(name, DATA_FOLDER+"/"), path.Ext(name)), name); err != nil {
+			// the key collision, like data/talks.yml and data/talks/
+			return err
+		}
+
+		// the nested key by the sub-folder
+		keys := strings.Split(strings.TrimPrefix(name, DATA_FOLDER+"/"), "/")
+		node := data
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := node[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[key] = child
+			}
+			node = child
+		}
+
+		key := keys[len(keys)-1]
+		node[strings.TrimSuffix(key, path.Ext(key))] = value
+
+		log.WithFields(log.Fields{
+			"path": name,
+		}).Trace("load the data file")
+		return nil
+	})
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("cannot load the data files")
+		return
+	}
+
+	config.data = data
+	return
+}
+
+// the site data loaded from the data folder, exposed as .Data in templates
+func (config Config) Data() (data map[string]interface{}) {
+	data = config.data
+	return
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadData(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/projects.yml":    {Data: []byte("- name: gitup\n")},
+		"data/talks/2022.json": {Data: []byte(`[{"title": "GitOps"}]`)},
+		"data/blogroll.csv":    {Data: []byte("name,url\ncmj,https://cmj.tw\n")},
+		"data/README.md":       {Data: []byte("# the data files #\n")},
+	}
+
+	conf := Config{}
+	conf.SetSource(fsys)
+	if err := conf.LoadData(); err != nil {
+		t.Fatalf("cannot load the data: %v", err)
+	}
+
+	tmpl := template.Must(template.New("test").Parse(
+		`{{ range .Data.projects }}{{ .name }}{{ end }}|` +
+			`{{ range index .Data.talks "2022" }}{{ .title }}{{ end }}|` +
+			`{{ range .Data.blogroll }}{{ .url }}{{ end }}`,
+	))
+
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, &conf); err != nil {
+		t.Fatalf("cannot execute the template: %v", err)
+	}
+	if text := buff.String(); text != "gitup|GitOps|https://cmj.tw" {
+		t.Errorf("invalid data: %v", text)
+	}
+
+	// reload the changed data files
+	fsys["data/projects.yml"] = &fstest.MapFile{Data: []byte("- name: gitdown\n")}
+	if err := conf.LoadData(); err != nil {
+		t.Fatalf("cannot reload the data: %v", err)
+	}
+	if name := conf.Data()["projects"].([]interface{})[0].(map[string]interface{})["name"]; name != "gitdown" {
+		t.Errorf("expect the reloaded data: %v", name)
+	}
+
+	fsys["data/broken.json"] = &fstest.MapFile{Data: []byte("{")}
+	if err := conf.LoadData(); err == nil {
+		t.Errorf("expect the invalid data file")
+	}
+	delete(fsys, "data/broken.json")
+
+	for name, expect := range map[string]string{
+		"data/talks.yml":    "data/talks.yml: the data key talks is already defined by data/talks",
+		"data/projects.csv": "data/projects.yml: the data key projects is already defined by data/projects.csv",
+	} {
+		fsys[name] = &fstest.MapFile{Data: []byte("[]\n")}
+		if err := conf.LoadData(); err == nil || err.Error() != expect {
+			t.Errorf("expect the key collision %v: %v", expect, err)
+		}
+		delete(fsys, name)
+	}
+}
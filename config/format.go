@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+)
+
+const (
+	// the output of the external converter
+	CONVERTER_OUTPUT_HTML     = "html"
+	CONVERTER_OUTPUT_MARKDOWN = "markdown"
+)
+
+// the external converter command of the source format, like asciidoctor for
+// the .adoc, the source is passed by the stdin and the converted one is read
+// from the stdout
+type Converter struct {
+	// the command and the arguments
+	Command []string `yaml:"command"`
+
+	// the output of the command, the HTML fragment by default or markdown
+	Output string `yaml:"output,omitempty"`
+}
+
+// check the command and the output of the converter
+func (converter Converter) validate() (err error) {
+	switch converter.Output {
+	case "", CONVERTER_OUTPUT_HTML, CONVERTER_OUTPUT_MARKDOWN:
+		// the valid output
+	default:
+		err = fmt.Errorf("unknown output: %v", converter.Output)
+		return
+	}
+
+	if len(converter.Command) == 0 || converter.Command[0] == "" {
+		err = fmt.Errorf("the command is required")
+		return
+	}
+	return
+}
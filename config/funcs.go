@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	// the time zone database for the minimal container image
+	_ "time/tzdata"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// the folder of the translations, like i18n/en.yml
+	I18N_FOLDER = "i18n"
+	// the default language of the site
+	DEFAULT_LANGUAGE = "en"
+
+	// the suffix of the truncated text
+	TRUNCATE_SUFFIX = "…"
+	// the length of the asset fingerprint
+	FINGERPRINT_SIZE = 8
+)
+
+var (
+	// the HTML tag
+	RE_HTML_TAG = regexp.MustCompile(`<[^>]*>`)
+	// the characters not allowed in the slug
+	RE_SLUG = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+	// render the markdown snippet by the configured engine, extensions and
+	// flags, registered by the blog package
+	MarkdownRenderer func(config *Config, text string) (html template.HTML, err error)
+)
+
+// the function library of the template
+//
+//	safe      TEXT              the text as the HTML without escaping
+//	indent    NUM TEXT          indent the new lines of the text by NUM spaces
+//	css       TEXT              the text as the CSS without escaping
+//	date      LAYOUT TZ TIME    format the time in the time zone, UTC if empty
+//	plainify  TEXT              strip the HTML tags of the text
+//	truncate  NUM TEXT          truncate the text to NUM characters
+//	wordcount TEXT              the number of the words, each CJK character is one word
+//	markdown  TEXT              render the markdown snippet by the markdown config
+//	slugify   TEXT              the URL-safe slug of the text
+//	absURL    LINK              the link joined with the settings.base_url
+//	data      PATH              read the YAML, JSON or CSV file in the repository
+//	asset     PATH              the asset link with the content fingerprint
+//	i18n      KEY               the translation of the settings.language, the key if missing
+func (config Config) FuncMap() (funcs template.FuncMap) {
+	var translations map[string]string
+
+	funcs = template.FuncMap{
+		"safe": func(text string) template.HTML {
+			return template.HTML(text)
+		},
+		"indent": func(num_indent int, text interface{}) template.HTML {
+			indent := "\n" + strings.Repeat(" ", num_indent)
+			return template.HTML(strings.Replace(to_string(text), "\n", indent, -1))
+		},
+		"css": func(text interface{}) template.CSS {
+			return template.CSS(to_string(text))
+		},
+		"date":      format_date,
+		"plainify":  plainify,
+		"truncate":  truncate,
+		"wordcount": wordcount,
+		"markdown": func(text interface{}) (html template.HTML, err error) {
+			if MarkdownRenderer == nil {
+				err = fmt.Errorf("no markdown renderer")
+				return
+			}
+
+			html, err = MarkdownRenderer(&config, to_string(text))
+			return
+		},
+		"slugify": Slugify,
+		"absURL":  config.AbsURL,
+		"data": func(name string) (value interface{}, err error) {
+			var data []byte
+			if data, err = fs.ReadFile(config.fsys(), path.Clean(name)); err != nil {
+				// cannot read the data file
+				return
+			}
+
+			value, err = DecodeData(name, data)
+			return
+		},
+		"asset": func(name string) (link string, err error) {
+			var data []byte
+			if data, err = fs.ReadFile(config.fsys(), path.Clean(name)); err != nil {
+				// cannot read the asset
+				return
+			}
+
+			if config.Fingerprint {
+				// renamed to the fingerprinted name, like site.1234abcd.css,
+				// by the post-processing which rewrites the link
+				link = name
+				return
+			}
+
+			link = fmt.Sprintf("%v?v=%v", name, Fingerprint(data))
+			return
+		},
+		"i18n": func(key string) (text string) {
+			if translations == nil {
+				translations = config.translations()
+			}
+
+			if text = translations[key]; text == "" {
+				// fallback to the key
+				text = key
+			}
+			return
+		},
+	}
+
+	return
+}
+
+// load the translations of the settings.language
+func (config Config) translations() (translations map[string]string) {
+	translations = map[string]string{}
+
+	language := config.Language
+	if language == "" {
+		language = DEFAULT_LANGUAGE
+	}
+
+	for _, ext := range []string{".yml", ".yaml", ".json"} {
+		data, err := fs.ReadFile(config.fsys(), path.Join(I18N_FOLDER, language+ext))
+		if err != nil {
+			// try the next one
+			continue
+		}
+
+		yaml.Unmarshal(data, &translations) // nolint
+		break
+	}
+
+	return
+}
+
+// format the time in the time zone, like Asia/Taipei, the UTC if empty
+func format_date(layout, tz string, t time.Time) (text string, err error) {
+	var location *time.Location
+	if location, err = time.LoadLocation(tz); err != nil {
+		// invalid time zone
+		return
+	}
+
+	text = t.In(location).Format(layout)
+	return
+}
+
+// strip the HTML tags of the text
+func plainify(text interface{}) (plain string) {
+	plain = RE_HTML_TAG.ReplaceAllString(to_string(text), "")
+	return
+}
+
+// truncate the text to the number of the characters, with the suffix if
+// truncated, the non-positive size is the empty text
+func truncate(size int, text interface{}) (truncated string) {
+	if size <= 0 {
+		// nothing is kept
+		return
+	}
+
+	truncated = to_string(text)
+	if utf8.RuneCountInString(truncated) <= size {
+		// short enough
+		return
+	}
+
+	runes := []rune(truncated)
+	truncated = strings.TrimRightFunc(string(runes[:size]), unicode.IsSpace) + TRUNCATE_SUFFIX
+	return
+}
+
+// the number of the words in the text, the HTML tags are ignored and each
+// CJK character is treated as one word
+func wordcount(text interface{}) (count int) {
+	count = WordCount(plainify(text))
+	return
+}
+
+// the number of the words in the plain text, split by the spaces and each
+// CJK character is treated as one word
+func WordCount(text string) (count int) {
+	for _, word := range strings.Fields(text) {
+		latin := false
+		for _, r := range word {
+			switch {
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+				if latin {
+					count++
+					latin = false
+				}
+				count++
+			default:
+				latin = true
+			}
+		}
+
+		if latin {
+			count++
+		}
+	}
+
+	return
+}
+
+// the URL-safe slug of the text, keep the unicode letters and numbers
+func Slugify(text string) (slug string) {
+	slug = RE_SLUG.ReplaceAllString(strings.ToLower(text), "-")
+	slug = strings.Trim(slug, "-")
+	return
+}
+
+// the short fingerprint of the content
+func Fingerprint(data []byte) (fingerprint string) {
+	sum := sha256.Sum256(data)
+	fingerprint = hex.EncodeToString(sum[:])[:FINGERPRINT_SIZE]
+	return
+}
+
+// decode the data file by the extension, the YAML, JSON or CSV with the
+// header line
+func DecodeData(name string, data []byte) (value interface{}, err error) {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".yml", ".yaml":
+		var node interface{}
+		if err = yaml.Unmarshal(data, &node); err != nil {
+			// invalid YAML
+			return
+		}
+
+		value = normalize(node)
+	case ".json":
+		err = json.Unmarshal(data, &value)
+	case ".csv":
+		var records [][]string
+		if records, err = csv.NewReader(bytes.NewReader(data)).ReadAll(); err != nil || len(records) == 0 {
+			// invalid CSV or empty
+			return
+		}
+
+		rows := []interface{}{}
+		for _, record := range records[1:] {
+			row := map[string]interface{}{}
+			for idx, key := range records[0] {
+				if idx < len(record) {
+					row[key] = record[idx]
+				}
+			}
+			rows = append(rows, row)
+		}
+		value = rows
+	default:
+		err = fmt.Errorf("unsupported data file: %v", name)
+	}
+
+	return
+}
+
+// convert the YAML map to the string-keyed map, same as the JSON
+func normalize(node interface{}) (value interface{}) {
+	switch node := node.(type) {
+	case map[interface{}]interface{}:
+		nodes := map[string]interface{}{}
+		for key, child := range node {
+			nodes[fmt.Sprintf("%v", key)] = normalize(child)
+		}
+		value = nodes
+	case []interface{}:
+		nodes := make([]interface{}, 0, len(node))
+		for _, child := range node {
+			nodes = append(nodes, normalize(child))
+		}
+		value = nodes
+	default:
+		value = node
+	}
+
+	return
+}
+
+// the text of the template value
+func to_string(text interface{}) (str string) {
+	switch text := text.(type) {
+	case string:
+		str = text
+	case template.HTML:
+		str = string(text)
+	default:
+		str = fmt.Sprintf("%v", text)
+	}
+
+	return
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFuncMap(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/projects.yml": {Data: []byte("- name: gitup\n  stars: 42\n")},
+		"data/talks.json":   {Data: []byte(`[{"title": "GitOps"}]`)},
+		"data/blogroll.csv": {Data: []byte("name,url\ncmj,https://cmj.tw\n")},
+		"static/site.css":   {Data: []byte("body {}")},
+		"i18n/zh-tw.yml":    {Data: []byte("posts: 文章\n")},
+	}
+
+	conf := Config{}
+	conf.BaseURL = "https://blog.example.com/"
+	conf.Language = "zh-tw"
+	conf.SetSource(fsys)
+
+	// the markdown renderer is registered by the blog package
+	MarkdownRenderer = func(config *Config, text string) (template.HTML, error) {
+		return template.HTML("<p>" + text + "@" + config.Language + "</p>\n"), nil
+	}
+	defer func() { MarkdownRenderer = nil }()
+
+	created := time.Date(2022, 12, 31, 20, 0, 0, 0, time.UTC)
+	cases := []struct {
+		tmpl   string
+		expect string
+	}{
+		{`{{ "<b>" | safe }}`, "<b>"},
+		{`{{ "a\nb" | indent 2 }}`, "a\n  b"},
+		{`{{ date "2006-01-02 15:04" "Asia/Taipei" .Created }}`, "2023-01-01 04:00"},
+		{`{{ date "2006-01-02 15:04" "" .Created }}`, "2022-12-31 20:00"},
+		{`{{ "<p>Hello <b>World</b></p>" | plainify }}`, "Hello World"},
+		{`{{ truncate 5 "Hello World" }}`, "Hello…"},
+		{`{{ truncate 20 "Hello World" }}`, "Hello World"},
+		{`{{ truncate 2 "你好世界" }}`, "你好…"},
+		{`{{ truncate 0 "Hello World" }}`, ""},
+		{`{{ truncate -1 "Hello World" }}`, ""},
+		{`{{ wordcount "<p>Hello, the World</p>" }}`, "3"},
+		{`{{ wordcount "Go 語言" }}`, "3"},
+		{`{{ markdown "**bold**" }}`, "<p>**bold**@zh-tw</p>\n"},
+		{`{{ slugify "Hello, World! 你好" }}`, "hello-world-你好"},
+		{`{{ absURL "/post-list.htm" }}`, "https://blog.example.com/post-list.htm"},
+		{`{{ range data "data/projects.yml" }}{{ .name }}:{{ .stars }}{{ end }}`, "gitup:42"},
+		{`{{ range data "data/talks.json" }}{{ .title }}{{ end }}`, "GitOps"},
+		{`{{ range data "data/blogroll.csv" }}{{ .name }}={{ .url }}{{ end }}`, "cmj=https://cmj.tw"},
+		{`{{ asset "static/site.css" }}`, "static/site.css?v=" + Fingerprint([]byte("body {}"))},
+		{`{{ i18n "posts" }}/{{ i18n "tags" }}`, "文章/tags"},
+	}
+
+	for _, c := range cases {
+		tmpl, err := template.New("test").Funcs(conf.FuncMap()).Parse(c.tmpl)
+		if err != nil {
+			t.Fatalf("cannot parse %v: %v", c.tmpl, err)
+		}
+
+		var buff bytes.Buffer
+		if err := tmpl.Execute(&buff, struct{ Created time.Time }{created}); err != nil {
+			t.Errorf("cannot execute %v: %v", c.tmpl, err)
+			continue
+		}
+
+		if text := buff.String(); text != template.HTMLEscapeString(c.expect) && text != c.expect {
+			t.Errorf("%v: expect %q: %q", c.tmpl, c.expect, text)
+		}
+	}
+
+	conf.Fingerprint = true
+	tmpl := template.Must(template.New("test").Funcs(conf.FuncMap()).Parse(`{{ asset "static/site.css" }}`))
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, nil); err != nil || buff.String() != "static/site.css" {
+		t.Errorf("expect the link rewritten by the fingerprint: %q %v", buff.String(), err)
+	}
+	conf.Fingerprint = false
+
+	invalid := []string{
+		`{{ date "2006" "Mars/Olympus" .Created }}`,
+		`{{ data "data/missing.yml" }}`,
+		`{{ data "static/site.css" }}`,
+		`{{ asset "static/missing.css" }}`,
+	}
+	for _, text := range invalid {
+		tmpl := template.Must(template.New("test").Funcs(conf.FuncMap()).Parse(text))
+		if err := tmpl.Execute(&bytes.Buffer{}, struct{ Created time.Time }{created}); err == nil {
+			t.Errorf("expect the error: %v", text)
+		}
+	}
+}
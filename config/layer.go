@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the prefix of the environment variables which override the config
+	ENV_PREFIX = "GITUP_"
+	// the environment variable of the user config path
+	ENV_USER_CONFIG = "GITUP_CONFIG"
+
+	// the name of the default layer
+	ORIGIN_DEFAULT = "default"
+)
+
+// the override of the config key, always applied after any config is loaded
+type override struct {
+	key    string
+	value  string
+	origin string
+}
+
+// the path of the global user config, like ~/.config/gitup/config.yml
+func UserConfigPath() (path string) {
+	if path = os.Getenv(ENV_USER_CONFIG); path != "" {
+		return
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		// cannot get the user config folder
+		return
+	}
+
+	for _, name := range []string{"config.yml", "config.yaml"} {
+		path = filepath.Join(dir, "gitup", name)
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+	}
+
+	path = ""
+	return
+}
+
+// load the global user config if exists
+func (config *Config) LoadUser() (err error) {
+	if path := UserConfigPath(); path != "" {
+		log.WithFields(log.Fields{
+			"path": path,
+		}).Debug("load the user config")
+
+		err = config.Load(path)
+	}
+
+	return
+}
+
+// override the config by the GITUP_* environment variables, like the
+// GITUP_RENDER_BRAND for the render.brand
+func (config *Config) LoadEnv(environ []string) (err error) {
+	envs := map[string]string{}
+	for _, key := range Keys() {
+		envs[EnvName(key.Name)] = key.Name
+	}
+
+	for _, env := range environ {
+		idx := strings.Index(env, "=")
+		if idx < 0 || !strings.HasPrefix(env, ENV_PREFIX) {
+			// not the GITUP_* environment variable
+			continue
+		}
+
+		name, value := env[:idx], env[idx+1:]
+		if key, ok := envs[name]; ok {
+			if err = config.Set(key, value, fmt.Sprintf("env %v", name)); err != nil {
+				// invalid value
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// the environment variable name of the config key
+func EnvName(key string) (name string) {
+	name = strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	name = ENV_PREFIX + name
+	return
+}
+
+// override the config key by the value, the value is decoded as YAML, like
+// the true for the boolean and [a, b] for the list
+func (config *Config) Set(key, value, origin string) (err error) {
+	entry := override{key: key, value: value, origin: origin}
+	if err = config.apply(entry); err != nil {
+		// invalid key or value
+		return
+	}
+
+	config.overrides = append(config.overrides, entry)
+	return
+}
+
+// apply the override to the config
+func (config *Config) apply(entry override) (err error) {
+	for _, key := range Keys() {
+		if key.Name != entry.key {
+			continue
+		}
+
+		value := reflect.New(key.Type)
+		if err = yaml.UnmarshalStrict([]byte(entry.value), value.Interface()); err != nil {
+			err = fmt.Errorf("%v: invalid value %q: %v", entry.key, entry.value, err)
+			return
+		}
+
+		reflect.ValueOf(config).Elem().FieldByIndex(key.Field).Set(value.Elem())
+		config.record(entry.key, entry.origin)
+		return
+	}
+
+	err = fmt.Errorf("unknown config key: %v", entry.key)
+	return
+}
+
+// re-apply all the overrides, which have the higher priority than the
+// config files
+func (config *Config) reapply() {
+	for _, entry := range config.overrides {
+		if err := config.apply(entry); err != nil {
+			log.WithFields(log.Fields{
+				"key":   entry.key,
+				"error": err,
+			}).Warn("cannot apply the override")
+		}
+	}
+}
+
+// record the keys set in the YAML text and where they come from
+func (config *Config) recordYAML(text []byte, origin string) {
+	var node map[interface{}]interface{}
+	if err := yaml.Unmarshal(text, &node); err != nil {
+		// invalid YAML, should be reported by the strict decoding
+		return
+	}
+
+	names := map[string]struct{}{}
+	for _, key := range Keys() {
+		names[key.Name] = struct{}{}
+	}
+
+	var walk func(prefix string, node map[interface{}]interface{})
+	walk = func(prefix string, node map[interface{}]interface{}) {
+		for name, value := range node {
+			key := fmt.Sprintf("%v%v", prefix, name)
+			if _, ok := names[key]; ok {
+				config.record(key, origin)
+				continue
+			}
+
+			if child, ok := value.(map[interface{}]interface{}); ok {
+				walk(key+".", child)
+			}
+		}
+	}
+	walk("", node)
+}
+
+// record where the config key comes from
+func (config *Config) record(key, origin string) {
+	if config.origins == nil {
+		config.origins = map[string]string{}
+	}
+
+	config.origins[key] = origin
+}
+
+// the origin of the config key, the default if never set
+func (config *Config) Origin(key string) (origin string) {
+	if origin = config.origins[key]; origin == "" {
+		origin = ORIGIN_DEFAULT
+	}
+	return
+}
+
+// explain every effective config value and where it comes from
+func (config *Config) Explain() (text string) {
+	keys := Keys()
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+
+	var lines []string
+	for _, key := range keys {
+		value := reflect.ValueOf(config).Elem().FieldByIndex(key.Field).Interface()
+
+		var flow string
+		switch value := value.(type) {
+		case string:
+			flow = fmt.Sprintf("%q", strings.TrimSpace(value))
+		default:
+			// show the list and nested value as the JSON flow style
+			data, err := json.Marshal(value)
+			if err != nil {
+				// the nested value cannot be the JSON, like the profiles
+				data = []byte(fmt.Sprintf("%v", value))
+			}
+			if flow = string(data); flow == "null" {
+				flow = "[]"
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf("%-36v = %-32v # %v", key.Name, flow, config.Origin(key.Name)))
+	}
+
+	text = strings.Join(lines, "\n")
+	return
+}
@@ -0,0 +1,37 @@
+package config
+
+const (
+	// the default maximum length of the line in the blog/markdown
+	LINT_MAX_LINE_LENGTH = 120
+)
+
+// the configurable rules of the blog/markdown lint
+type Lint struct {
+	// the disabled rules, like long-line
+	Disabled []string `yaml:"disabled,omitempty"`
+
+	// override the severity of the rule, can be error, warning or note
+	Severity map[string]string `yaml:"severity,omitempty"`
+
+	// the maximum length of the line, 120 by default
+	MaxLineLength int `yaml:"max_line_length,omitempty"`
+}
+
+// the rule is disabled or not
+func (lint Lint) IsDisabled(rule string) (disabled bool) {
+	for _, name := range lint.Disabled {
+		if name == rule {
+			disabled = true
+			break
+		}
+	}
+	return
+}
+
+// the maximum length of the line, the default one if not set
+func (lint Lint) LineLength() (length int) {
+	if length = lint.MaxLineLength; length <= 0 {
+		length = LINT_MAX_LINE_LENGTH
+	}
+	return
+}
@@ -0,0 +1,14 @@
+package config
+
+// enable or disable the markdown parser extensions and the HTML flags by the
+// name, like titleblock: false, the unset one keeps the default
+type Markdown struct {
+	// the markdown engine, gomarkdown by default or gfm
+	Engine string `yaml:"engine,omitempty"`
+
+	// the parser extensions, like titleblock, mmark and footnotes
+	Extensions map[string]bool `yaml:"extensions,omitempty"`
+
+	// the HTML flags, like href_target_blank, nofollow_links and toc
+	Flags map[string]bool `yaml:"flags,omitempty"`
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// the suffix of the generated page
+	PAGE_SUFFIX = ".htm"
+)
+
+// the standalone page rendered from the blog/markdown, like the about-me,
+// and listed in the navigation menu
+type Page struct {
+	// the path of the blog/markdown
+	Source string `yaml:"source"`
+
+	// the output name, the slug of the source by default
+	Output string `yaml:"output,omitempty"`
+
+	// the title and the Font Awesome icon in the navigation menu, like
+	// fa-id-card
+	Title string `yaml:"title,omitempty"`
+	Icon  string `yaml:"icon,omitempty"`
+
+	// the order in the navigation menu, the smaller one first
+	Order int `yaml:"order,omitempty"`
+
+	// the layout of the theme, like page.htm, the blog layout by default
+	Layout string `yaml:"layout,omitempty"`
+}
+
+// the output name of the page
+func (page Page) Link() (link string) {
+	if link = page.Output; link == "" {
+		name := path.Base(page.Source)
+		link = Slugify(strings.TrimSuffix(name, path.Ext(name))) + PAGE_SUFFIX
+	}
+
+	return
+}
+
+// all the standalone pages in the navigation order, the about-me and the
+// license pages are listed first
+func (settings Settings) NavPages() (pages []Page) {
+	if settings.AboutMe != "" {
+		pages = append(pages, Page{
+			Source: settings.AboutMe,
+			Output: "about-me.htm",
+			Title:  "About Me",
+			Icon:   "fa-id-card",
+		})
+	}
+	if settings.License != "" {
+		pages = append(pages, Page{
+			Source: settings.License,
+			Output: "license.htm",
+			Title:  "License",
+			Icon:   "fa-copyright",
+		})
+	}
+
+	pages = append(pages, settings.Pages...)
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Order < pages[j].Order })
+	return
+}
+
+// the reserved output names without the extension, the index, the post-list
+// and the standalone pages
+func (settings Settings) ReservedNames() (names []string) {
+	names = []string{"index", "post-list"}
+	for _, page := range settings.NavPages() {
+		names = append(names, strings.TrimSuffix(page.Link(), path.Ext(page.Link())))
+	}
+	return
+}
+
+// check the output name of the page is the file in the site root
+func (page Page) validate() (err error) {
+	link := page.Link()
+
+	switch {
+	case page.Source == "":
+		err = fmt.Errorf("the source is required")
+	case strings.ContainsAny(link, `/\`) || link[0] == '.':
+		err = fmt.Errorf("invalid output name: %v", link)
+	case link == "index"+PAGE_SUFFIX || link == "post-list"+PAGE_SUFFIX:
+		err = fmt.Errorf("the output name %v is reserved", link)
+	}
+
+	return
+}
+
+// get the HTML template of the layout, the blog layout if not set
+func (config Config) LayoutTemplate(layout string) (tmpl *template.Template, err error) {
+	if layout == "" {
+		tmpl, err = config.Template()
+		return
+	}
+
+	var text string
+	if text, err = config.layout("", layout); err != nil {
+		// cannot get the template text
+		return
+	}
+
+	tmpl, err = config.renderTemplate(text)
+	return
+}
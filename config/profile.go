@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// choose the named profile, which overlays the base config when the config
+// defines the profile is loaded
+func (config *Config) UseProfile(name string) {
+	config.profile = name
+}
+
+// the name of the active profile
+func (config *Config) Profile() (name string) {
+	name = config.profile
+	return
+}
+
+// check the active profile is defined in any loaded config
+func (config *Config) CheckProfile() (err error) {
+	if config.profile == "" || config.profile_loaded {
+		// no profile or found
+		return
+	}
+
+	names := []string{}
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	err = fmt.Errorf("profile %v not found, available: %v", config.profile, names)
+	return
+}
+
+// the profile defined in the loaded config, checked strictly by the config
+// check even when not active
+type profile_layer struct {
+	name   string // the name of the profile
+	origin string // where the profile is defined
+	data   []byte // the YAML text of the profile
+	err    error  // the profile cannot be the config
+}
+
+// overlay the active profile defined in the YAML text, and keep all the
+// profiles for the config check
+func (config *Config) overlay(text []byte, origin string) (err error) {
+	var doc struct {
+		Profiles map[string]yaml.MapSlice `yaml:"profiles"`
+	}
+	if err = yaml.Unmarshal(text, &doc); err != nil {
+		// invalid YAML, should be reported by the strict decoding
+		return
+	}
+
+	names := []string{}
+	for name := range doc.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		layer := profile_layer{name: name, origin: origin}
+		layer.data, layer.err = marshal_profile(name, doc.Profiles[name])
+		config.profiles = append(config.profiles, layer)
+	}
+
+	if config.profile == "" {
+		// no active profile
+		return
+	}
+
+	profile, ok := doc.Profiles[config.profile]
+	if !ok {
+		// the profile is not defined in this config
+		return
+	}
+
+	var data []byte
+	if data, err = marshal_profile(config.profile, profile); err != nil {
+		// invalid profile
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"profile": config.profile,
+		"path":    origin,
+	}).Debug("overlay the profile")
+
+	if err = yaml.UnmarshalStrict(data, config); err != nil {
+		err = fmt.Errorf("profile %v: %v", config.profile, suggest(err))
+		return
+	}
+
+	config.recordYAML(data, fmt.Sprintf("profile %v (%v)", config.profile, origin))
+	config.profile_loaded = true
+	return
+}
+
+// the YAML text of the profile, the nested profiles are not allowed
+func marshal_profile(name string, profile yaml.MapSlice) (data []byte, err error) {
+	for _, item := range profile {
+		if key, _ := item.Key.(string); key == "profiles" {
+			err = fmt.Errorf("profile %v: cannot define the nested profiles", name)
+			return
+		}
+	}
+
+	data, err = yaml.Marshal(profile)
+	return
+}
+
+// decode every profile defined in the loaded configs strictly, the problem
+// is reported with where the profile is defined
+func (config *Config) check_profiles() (errs ValidationError) {
+	for _, layer := range config.profiles {
+		err := layer.err
+		if err == nil {
+			var dummy Config
+			if err = yaml.UnmarshalStrict(layer.data, &dummy); err != nil {
+				err = fmt.Errorf("profile %v: %v", layer.name, suggest(err))
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", layer.origin, err))
+		}
+	}
+
+	return
+}
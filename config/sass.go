@@ -0,0 +1,876 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// the max depth of the nested import and mixin
+	SASS_MAX_DEPTH = 16
+)
+
+var (
+	// the variable reference, like $fg_color
+	RE_SASS_VARIABLE = regexp.MustCompile(`\$[\w-]+`)
+	// the interpolation, like #{$name}
+	RE_SASS_INTERPOLATION = regexp.MustCompile(`#\{([^}]*)\}`)
+	// the number without the leading zero, like .5rem
+	RE_SASS_LEADING_ZERO = regexp.MustCompile(`(^|[\s(,:/+*-])\.(\d)`)
+	// the mixin or include with the optional arguments, like name($a, $b)
+	RE_SASS_MIXIN = regexp.MustCompile(`^([\w-]+)\s*(?:\((.*)\))?$`)
+	// the name of the at-rule, like @media
+	RE_SASS_AT_RULE = regexp.MustCompile(`^@([\w-]+)`)
+	// the function call in the value, like rgba( or -webkit-linear-gradient(
+	RE_SASS_FUNCTION = regexp.MustCompile(`(^|[^\w-])((?:-\w+-)?([a-zA-Z][\w-]*))\(`)
+	// the arithmetic of the SASS which is not the plain CSS, like $a * 2,
+	// 1px + 2px, $a / 2 or 10 % 3, the -$a is the negative value
+	RE_SASS_ARITHMETIC = regexp.MustCompile(`\*|(?:^|[^uU])\+|\s-\s|\$[\w-]+\s*[/%]|/\s*\$|\s%`)
+
+	// the SASS at-rules which are not supported by the built-in compiler
+	SASS_UNSUPPORTED_RULES = []string{
+		"at-root", "content", "debug", "each", "else", "error", "extend", "for",
+		"forward", "function", "if", "return", "use", "warn", "while",
+	}
+
+	// the CSS functions kept as-is, the other functions are the SASS
+	// functions, like darken() and map-get()
+	CSS_FUNCTIONS = []string{
+		"abs", "acos", "anchor", "anchor-size", "asin", "atan", "atan2", "attr", "blur",
+		"brightness", "calc", "circle", "clamp", "color", "color-mix", "conic-gradient",
+		"contrast", "cos", "counter", "counters", "cross-fade", "cubic-bezier",
+		"drop-shadow", "element", "ellipse", "env", "exp", "fit-content", "format",
+		"grayscale", "hsl", "hsla", "hue-rotate", "hwb", "hypot", "image", "image-set",
+		"inset", "invert", "lab", "lch", "light-dark",
+		"linear-gradient", "local", "log", "matrix", "matrix3d", "max", "min", "minmax",
+		"mod", "oklab", "oklch", "opacity", "paint", "path", "perspective", "polygon",
+		"pow", "radial-gradient", "ray", "rect", "rem", "repeat", "repeating-conic-gradient",
+		"repeating-linear-gradient", "repeating-radial-gradient", "rgb", "rgba",
+		"rotate", "rotate3d", "rotatex", "rotatey", "rotatez", "round", "saturate",
+		"scale", "scale3d", "scalex", "scaley", "scalez", "sepia", "sign", "sin",
+		"skew", "skewx", "skewy", "sqrt", "steps", "symbols", "tan", "tech", "translate",
+		"translate3d", "translatex", "translatey", "translatez", "url", "var", "xywh",
+	}
+)
+
+// the importer of the SASS/SCSS, return the text of the imported file and
+// the file is the indented syntax or not
+type SASSImporter func(name string) (text []byte, indented bool, err error)
+
+// compile the SASS (the indented syntax) or the SCSS to CSS, support the
+// variables, the nesting with the parent selector, the mixins, the imports
+// and the at-rules like the @media
+func CompileSASS(text []byte, indented bool, importer SASSImporter) (css []byte, err error) {
+	compiler := &sass_compiler{
+		importer: importer,
+		mixins:   map[string]*sass_node{},
+	}
+
+	var nodes []*sass_node
+	if nodes, err = parse_sass(text, indented); err != nil {
+		// invalid syntax
+		return
+	}
+
+	var items []*css_item
+	if _, items, err = compiler.eval(nodes, nil, &sass_scope{vars: map[string]string{}}, true); err != nil {
+		// cannot evaluate
+		return
+	}
+
+	var buff bytes.Buffer
+	write_css(&buff, items, "")
+	css = buff.Bytes()
+	return
+}
+
+// the statement of the SASS/SCSS, may have the nested statements
+type sass_node struct {
+	line     int
+	text     string
+	block    bool
+	children []*sass_node
+}
+
+// parse the SASS/SCSS to the statements
+func parse_sass(text []byte, indented bool) (nodes []*sass_node, err error) {
+	switch indented {
+	case true:
+		nodes, err = parse_sass_indented(string(text))
+	case false:
+		nodes, err = parse_scss(string(text))
+	}
+	return
+}
+
+// parse the indented syntax, the nesting is decided by the indentation
+func parse_sass_indented(text string) (nodes []*sass_node, err error) {
+	type frame struct {
+		indent int
+		node   *sass_node
+	}
+
+	root := &sass_node{block: true}
+	stack := []frame{{indent: -1, node: root}}
+	comment := -1 // the indentation of the multi-line comment
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		line := strings.TrimRight(lines[idx], " \t")
+		content := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(content)
+
+		switch {
+		case content == "":
+			continue
+		case comment >= 0 && indent > comment:
+			// inside the multi-line comment
+			continue
+		case strings.HasPrefix(content, "//") || strings.HasPrefix(content, "/*"):
+			comment = indent
+			continue
+		}
+		comment = -1
+
+		line_no := idx + 1
+		content = strip_comment(content)
+		// the selector list may continue on the next line
+		for strings.HasSuffix(content, ",") && idx+1 < len(lines) {
+			idx++
+			content += " " + strip_comment(strings.TrimSpace(lines[idx]))
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1].node
+		node := &sass_node{line: line_no, text: strings.TrimSuffix(content, ";")}
+		parent.children = append(parent.children, node)
+		parent.block = true
+
+		stack = append(stack, frame{indent: indent, node: node})
+	}
+
+	nodes = root.children
+	return
+}
+
+// parse the SCSS syntax, the nesting is decided by the braces
+func parse_scss(text string) (nodes []*sass_node, err error) {
+	root := &sass_node{block: true}
+	stack := []*sass_node{root}
+
+	var buff strings.Builder
+	line, start := 1, 1
+	quote, paren := rune(0), 0
+
+	runes := []rune(text)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+		if r == '\n' {
+			line++
+		}
+
+		switch {
+		case quote != 0:
+			if r == quote && runes[idx-1] != '\\' {
+				quote = 0
+			}
+			buff.WriteRune(r)
+			continue
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			paren++
+		case r == ')':
+			paren--
+		case r == '/' && idx+1 < len(runes) && runes[idx+1] == '/' && paren == 0:
+			// the single-line comment
+			for idx < len(runes) && runes[idx] != '\n' {
+				idx++
+			}
+			line++
+			continue
+		case r == '/' && idx+1 < len(runes) && runes[idx+1] == '*':
+			// the multi-line comment
+			end := strings.Index(string(runes[idx+2:]), "*/")
+			if end < 0 {
+				err = fmt.Errorf("line %d: unclosed comment", line)
+				return
+			}
+			comment := string(runes[idx : idx+2+end+2])
+			line += strings.Count(comment, "\n")
+			idx += len([]rune(comment)) - 1
+			continue
+		case r == '#' && idx+1 < len(runes) && runes[idx+1] == '{':
+			// the interpolation
+			end := strings.IndexRune(string(runes[idx:]), '}')
+			if end < 0 {
+				err = fmt.Errorf("line %d: unclosed interpolation", line)
+				return
+			}
+			buff.WriteString(string(runes[idx : idx+end+1]))
+			idx += end
+			continue
+		case paren > 0:
+		case r == '{':
+			node := &sass_node{line: start, text: collapse_space(buff.String()), block: true}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+
+			buff.Reset()
+			start = line
+			continue
+		case r == ';' || r == '}':
+			if content := collapse_space(buff.String()); content != "" {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &sass_node{line: start, text: content})
+			}
+			buff.Reset()
+			start = line
+
+			if r == '}' {
+				if len(stack) == 1 {
+					err = fmt.Errorf("line %d: unexpected }", line)
+					return
+				}
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		if buff.Len() == 0 && (r == ' ' || r == '\t' || r == '\n' || r == '\r') {
+			start = line
+			continue
+		}
+		buff.WriteRune(r)
+	}
+
+	switch {
+	case len(stack) > 1:
+		err = fmt.Errorf("line %d: unclosed {", stack[len(stack)-1].line)
+	case strings.TrimSpace(buff.String()) != "":
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, &sass_node{line: start, text: collapse_space(buff.String())})
+	}
+
+	nodes = root.children
+	return
+}
+
+// the scope of the variables
+type sass_scope struct {
+	vars   map[string]string
+	parent *sass_scope
+}
+
+func (scope *sass_scope) lookup(name string) (value string, ok bool) {
+	for ; scope != nil; scope = scope.parent {
+		if value, ok = scope.vars[name]; ok {
+			return
+		}
+	}
+	return
+}
+
+// set the variable, override the one in the outer scope if exists
+func (scope *sass_scope) set(name, value string) {
+	for curr := scope; curr != nil; curr = curr.parent {
+		if _, ok := curr.vars[name]; ok {
+			curr.vars[name] = value
+			return
+		}
+	}
+
+	scope.vars[name] = value
+}
+
+// the compiled CSS, the style rule, the at-rule or the raw statement
+type css_item struct {
+	selectors []string
+	prelude   string
+	raw       bool
+	decls     []string
+	items     []*css_item
+	group     int
+}
+
+type sass_compiler struct {
+	importer SASSImporter
+	mixins   map[string]*sass_node
+	depth    int
+	group    int
+}
+
+// evaluate the statements with the parent selectors, return the
+// declarations of the parent and the nested items
+func (compiler *sass_compiler) eval(nodes []*sass_node, parents []string, scope *sass_scope, top bool) (decls []string, items []*css_item, err error) {
+	if compiler.depth++; compiler.depth > SASS_MAX_DEPTH {
+		err = fmt.Errorf("too deep nesting of the import or mixin")
+		return
+	}
+	defer func() { compiler.depth-- }()
+
+	for _, node := range nodes {
+		if top {
+			compiler.group++
+		}
+
+		var node_decls []string
+		var node_items []*css_item
+		if node_decls, node_items, err = compiler.eval_node(node, parents, scope); err != nil {
+			if !strings.HasPrefix(err.Error(), "line ") {
+				err = fmt.Errorf("line %d: %v", node.line, err)
+			}
+			return
+		}
+
+		decls = append(decls, node_decls...)
+		for _, item := range node_items {
+			if item.group == 0 {
+				item.group = compiler.group
+			}
+		}
+		items = append(items, node_items...)
+	}
+
+	return
+}
+
+func (compiler *sass_compiler) eval_node(node *sass_node, parents []string, scope *sass_scope) (decls []string, items []*css_item, err error) {
+	text := node.text
+
+	switch {
+	case strings.HasPrefix(text, "$"):
+		// the variable
+		idx := strings.Index(text, ":")
+		if idx < 0 {
+			err = fmt.Errorf("invalid variable: %v", text)
+			return
+		}
+
+		name, value := strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+1:])
+		if strings.HasSuffix(value, "!default") {
+			if _, ok := scope.lookup(name); ok {
+				// already defined
+				return
+			}
+			value = strings.TrimSpace(strings.TrimSuffix(value, "!default"))
+		}
+
+		if value, err = compiler.value(value, scope); err != nil {
+			// invalid variable reference
+			return
+		}
+
+		scope.set(name, value)
+	case strings.HasPrefix(text, "@mixin ") || strings.HasPrefix(text, "="):
+		// the mixin definition
+		decl := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "@mixin "), "="))
+		matched := RE_SASS_MIXIN.FindStringSubmatch(decl)
+		if matched == nil {
+			err = fmt.Errorf("invalid mixin: %v", text)
+			return
+		}
+
+		compiler.mixins[matched[1]] = node
+	case strings.HasPrefix(text, "@include ") || strings.HasPrefix(text, "+"):
+		// the mixin call
+		decls, items, err = compiler.include(node, parents, scope)
+	case strings.HasPrefix(text, "@import "):
+		items, err = compiler.imports(strings.TrimSpace(text[len("@import "):]), parents, scope, &decls)
+	case strings.HasPrefix(text, "@charset "):
+		items = append(items, &css_item{prelude: text, raw: true})
+	case is_unsupported_rule(text):
+		err = fmt.Errorf("unsupported SASS feature: %v", RE_SASS_AT_RULE.FindString(text))
+	case strings.HasPrefix(text, "@") && node.block:
+		// the at-rule, like @media and @font-face
+		var prelude string
+		if prelude, err = compiler.interpolate(text, scope); err != nil {
+			return
+		}
+
+		if strings.HasPrefix(prelude, "@media") {
+			prelude = collapse_space(prelude)
+		}
+
+		var block_decls []string
+		var block_items []*css_item
+		child := &sass_scope{vars: map[string]string{}, parent: scope}
+		if block_decls, block_items, err = compiler.eval(node.children, parents, child, false); err != nil {
+			return
+		}
+
+		block := &css_item{prelude: prelude}
+		switch {
+		case len(block_decls) > 0 && len(parents) > 0:
+			block.items = append(block.items, &css_item{selectors: parents, decls: block_decls})
+		default:
+			block.decls = block_decls
+		}
+		block.items = append(block.items, block_items...)
+		items = append(items, block)
+	case node.block && strings.HasSuffix(text, ":"):
+		// the nested property, like font: with the family and size
+		prefix := strings.TrimSuffix(text, ":")
+		for _, child := range node.children {
+			var child_decls []string
+			if child_decls, _, err = compiler.eval_node(child, parents, scope); err != nil {
+				return
+			}
+			for _, decl := range child_decls {
+				decls = append(decls, prefix+"-"+decl)
+			}
+		}
+	case node.block:
+		// the style rule
+		var selector string
+		if selector, err = compiler.interpolate(text, scope); err != nil {
+			return
+		}
+
+		if strings.HasPrefix(selector, "%") || strings.Contains(selector, " %") || strings.Contains(selector, ",%") {
+			err = fmt.Errorf("unsupported SASS feature: the placeholder selector %v", selector)
+			return
+		}
+
+		selectors := resolve_selectors(parents, selector)
+		child := &sass_scope{vars: map[string]string{}, parent: scope}
+
+		var rule_decls []string
+		var rule_items []*css_item
+		if rule_decls, rule_items, err = compiler.eval(node.children, selectors, child, false); err != nil {
+			return
+		}
+
+		if len(rule_decls) > 0 {
+			items = append(items, &css_item{selectors: selectors, decls: rule_decls})
+		}
+		items = append(items, rule_items...)
+	default:
+		// the declaration
+		idx := strings.Index(text, ":")
+		if idx <= 0 {
+			err = fmt.Errorf("invalid declaration: %v", text)
+			return
+		}
+
+		var name, value string
+		if name, err = compiler.interpolate(strings.TrimSpace(text[:idx]), scope); err != nil {
+			return
+		}
+
+		switch strings.HasPrefix(name, "--") {
+		case true:
+			// the custom property is kept as-is except the interpolations
+			value, err = compiler.interpolate(strings.TrimSpace(text[idx+1:]), scope)
+		case false:
+			value, err = compiler.value(strings.TrimSpace(text[idx+1:]), scope)
+		}
+		if err != nil {
+			return
+		}
+
+		decls = append(decls, fmt.Sprintf("%v: %v", name, value))
+	}
+
+	return
+}
+
+// call the mixin with the arguments
+func (compiler *sass_compiler) include(node *sass_node, parents []string, scope *sass_scope) (decls []string, items []*css_item, err error) {
+	call := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(node.text, "@include "), "+"))
+	matched := RE_SASS_MIXIN.FindStringSubmatch(call)
+	if matched == nil {
+		err = fmt.Errorf("invalid include: %v", node.text)
+		return
+	}
+
+	mixin, ok := compiler.mixins[matched[1]]
+	if !ok {
+		err = fmt.Errorf("undefined mixin: %v", matched[1])
+		return
+	}
+
+	define := RE_SASS_MIXIN.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(mixin.text, "@mixin "), "=")))
+	params, args := split_list(define[2]), split_list(matched[2])
+
+	local := &sass_scope{vars: map[string]string{}, parent: scope}
+	for idx, param := range params {
+		name, value := param, ""
+		if pos := strings.Index(param, ":"); pos >= 0 {
+			name, value = strings.TrimSpace(param[:pos]), strings.TrimSpace(param[pos+1:])
+		}
+
+		if idx < len(args) {
+			value = args[idx]
+		}
+		if value == "" {
+			err = fmt.Errorf("missing argument %v of mixin %v", name, matched[1])
+			return
+		}
+
+		if value, err = compiler.value(value, scope); err != nil {
+			return
+		}
+		local.vars[name] = value
+	}
+
+	decls, items, err = compiler.eval(mixin.children, parents, local, false)
+	return
+}
+
+// import the SASS/SCSS files, the plain CSS import is kept as-is
+func (compiler *sass_compiler) imports(text string, parents []string, scope *sass_scope, decls *[]string) (items []*css_item, err error) {
+	for _, name := range split_list(text) {
+		unquoted := strings.Trim(name, `"'`)
+		switch {
+		case strings.HasPrefix(name, "url("), strings.HasSuffix(unquoted, ".css"), strings.Contains(unquoted, "://"):
+			items = append(items, &css_item{prelude: "@import " + name, raw: true})
+			continue
+		case compiler.importer == nil:
+			err = fmt.Errorf("cannot import %v", unquoted)
+			return
+		}
+
+		var text []byte
+		var indented bool
+		if text, indented, err = compiler.importer(unquoted); err != nil {
+			err = fmt.Errorf("cannot import %v: %v", unquoted, err)
+			return
+		}
+
+		var nodes []*sass_node
+		if nodes, err = parse_sass(text, indented); err != nil {
+			err = fmt.Errorf("%v: %v", unquoted, err)
+			return
+		}
+
+		var import_decls []string
+		var import_items []*css_item
+		if import_decls, import_items, err = compiler.eval(nodes, parents, scope, false); err != nil {
+			err = fmt.Errorf("%v: %v", unquoted, err)
+			return
+		}
+
+		*decls = append(*decls, import_decls...)
+		items = append(items, import_items...)
+	}
+
+	return
+}
+
+// substitute the variables and the interpolations of the value, the SASS
+// expression is rejected rather than passed as the invalid CSS
+func (compiler *sass_compiler) value(text string, scope *sass_scope) (value string, err error) {
+	if err = check_expression(text); err != nil {
+		// the arithmetic or the SASS function
+		return
+	}
+
+	if value, err = compiler.interpolate(text, scope); err != nil {
+		return
+	}
+
+	value = RE_SASS_VARIABLE.ReplaceAllStringFunc(value, func(name string) string {
+		resolved, ok := scope.lookup(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable: %v", name)
+		}
+		return resolved
+	})
+
+	value = collapse_space(value)
+	value = RE_SASS_LEADING_ZERO.ReplaceAllString(value, "${1}0.${2}")
+	value = double_quote(value)
+	return
+}
+
+// substitute the interpolations, like #{$name}
+func (compiler *sass_compiler) interpolate(text string, scope *sass_scope) (value string, err error) {
+	value = RE_SASS_INTERPOLATION.ReplaceAllStringFunc(text, func(matched string) string {
+		name := strings.TrimSpace(matched[2 : len(matched)-1])
+		if !RE_SASS_VARIABLE.MatchString(name) || RE_SASS_VARIABLE.FindString(name) != name {
+			if err == nil {
+				err = fmt.Errorf("unsupported SASS feature: the expression %v", matched)
+			}
+			return matched
+		}
+
+		resolved, ok := scope.lookup(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable: %v", name)
+		}
+		return strings.Trim(resolved, `"'`)
+	})
+	return
+}
+
+// the SASS at-rule not supported by the built-in compiler, like @use and @if
+func is_unsupported_rule(text string) (unsupported bool) {
+	matched := RE_SASS_AT_RULE.FindStringSubmatch(text)
+	if matched == nil {
+		// not the at-rule
+		return
+	}
+
+	for _, name := range SASS_UNSUPPORTED_RULES {
+		if strings.EqualFold(matched[1], name) {
+			unsupported = true
+			return
+		}
+	}
+	return
+}
+
+// reject the arithmetic and the SASS functions in the value, the strings, the
+// interpolations and the arguments of url() and the CSS math functions are
+// kept as-is
+func check_expression(text string) (err error) {
+	plain := strip_expression(text)
+
+	if matched := RE_SASS_ARITHMETIC.FindString(plain); matched != "" {
+		err = fmt.Errorf("unsupported SASS feature: the arithmetic %v", strings.TrimSpace(text))
+		return
+	}
+
+	for _, matched := range RE_SASS_FUNCTION.FindAllStringSubmatch(plain, -1) {
+		known := false
+		for _, name := range CSS_FUNCTIONS {
+			if strings.EqualFold(matched[3], name) {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			err = fmt.Errorf("unsupported SASS feature: the function %v()", matched[2])
+			return
+		}
+	}
+
+	return
+}
+
+// remove the quoted strings, the interpolations and the arguments of url()
+// and the CSS math functions, which never contain the SASS expression
+func strip_expression(text string) (stripped string) {
+	var buff strings.Builder
+
+	quote, skipped, paren := rune(0), -1, 0
+	for idx := 0; idx < len(text); idx++ {
+		r := rune(text[idx])
+		switch {
+		case quote != 0:
+			if r == quote && text[idx-1] != '\\' {
+				quote = 0
+			}
+			continue
+		case r == '"' || r == '\'':
+			quote = r
+			continue
+		case r == '#' && idx+1 < len(text) && text[idx+1] == '{':
+			if end := strings.IndexByte(text[idx:], '}'); end >= 0 {
+				idx += end
+				buff.WriteString("0")
+				continue
+			}
+		case r == '(':
+			paren++
+			if skipped < 0 {
+				name := strings.ToLower(text[strings.LastIndexAny(text[:idx], " \t,(/")+1 : idx])
+				switch name {
+				case "url", "calc", "min", "max", "clamp":
+					buff.WriteString("(")
+					skipped = paren
+				}
+			}
+		case r == ')':
+			if paren--; skipped > paren {
+				skipped = -1
+			}
+		}
+
+		if skipped < 0 {
+			buff.WriteByte(text[idx])
+		}
+	}
+
+	stripped = buff.String()
+	return
+}
+
+// resolve the nested selectors with the parent selectors
+func resolve_selectors(parents []string, selector string) (selectors []string) {
+	for _, child := range split_list(selector) {
+		if len(parents) == 0 {
+			selectors = append(selectors, strings.ReplaceAll(child, "&", ""))
+			continue
+		}
+
+		for _, parent := range parents {
+			switch strings.Contains(child, "&") {
+			case true:
+				selectors = append(selectors, strings.ReplaceAll(child, "&", parent))
+			case false:
+				selectors = append(selectors, parent+" "+child)
+			}
+		}
+	}
+
+	return
+}
+
+// write the compiled CSS as the expanded style
+func write_css(buff *bytes.Buffer, items []*css_item, indent string) {
+	for idx, item := range items {
+		if idx > 0 && indent == "" && item.group != items[idx-1].group {
+			// separate the top-level groups
+			buff.WriteString("\n")
+		}
+
+		switch {
+		case item.raw:
+			fmt.Fprintf(buff, "%v%v;\n", indent, item.prelude)
+		case item.prelude != "":
+			fmt.Fprintf(buff, "%v%v {\n", indent, item.prelude)
+			for _, decl := range item.decls {
+				fmt.Fprintf(buff, "%v  %v;\n", indent, decl)
+			}
+			write_css(buff, item.items, indent+"  ")
+			fmt.Fprintf(buff, "%v}\n", indent)
+		default:
+			fmt.Fprintf(buff, "%v%v {\n", indent, strings.Join(item.selectors, ",\n"+indent))
+			for _, decl := range item.decls {
+				fmt.Fprintf(buff, "%v  %v;\n", indent, decl)
+			}
+			fmt.Fprintf(buff, "%v}\n", indent)
+		}
+	}
+}
+
+// strip the trailing single-line comment outside the quotes and parentheses
+func strip_comment(text string) (stripped string) {
+	stripped = text
+
+	quote, paren := rune(0), 0
+	for idx, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			paren++
+		case r == ')':
+			paren--
+		case paren == 0 && strings.HasPrefix(text[idx:], "//"):
+			stripped = strings.TrimSpace(text[:idx])
+			return
+		}
+	}
+
+	return
+}
+
+// split the comma-separated list outside the quotes and parentheses
+func split_list(text string) (list []string) {
+	quote, paren, start := rune(0), 0, 0
+	for idx, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			paren++
+		case r == ')':
+			paren--
+		case r == ',' && paren == 0:
+			if item := strings.TrimSpace(text[start:idx]); item != "" {
+				list = append(list, item)
+			}
+			start = idx + 1
+		}
+	}
+
+	if item := strings.TrimSpace(text[start:]); item != "" {
+		list = append(list, item)
+	}
+	return
+}
+
+// collapse the whitespaces outside the quotes
+func collapse_space(text string) (collapsed string) {
+	var buff strings.Builder
+
+	quote, space := rune(0), false
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		}
+
+		if space {
+			buff.WriteRune(' ')
+			space = false
+		}
+		buff.WriteRune(r)
+	}
+
+	collapsed = buff.String()
+	return
+}
+
+// use the double quotes for the strings without the double quote
+func double_quote(text string) (quoted string) {
+	var buff strings.Builder
+
+	runes := []rune(text)
+	for idx := 0; idx < len(runes); idx++ {
+		switch runes[idx] {
+		case '"':
+			end := strings.IndexRune(string(runes[idx+1:]), '"')
+			if end < 0 {
+				buff.WriteString(string(runes[idx:]))
+				idx = len(runes)
+				continue
+			}
+			buff.WriteString(string(runes[idx : idx+end+2]))
+			idx += end + 1
+		case '\'':
+			end := strings.IndexRune(string(runes[idx+1:]), '\'')
+			content := ""
+			if end >= 0 {
+				content = string(runes[idx+1 : idx+1+end])
+			}
+
+			if end < 0 || strings.ContainsRune(content, '"') {
+				buff.WriteRune(runes[idx])
+				continue
+			}
+			buff.WriteString(`"` + content + `"`)
+			idx += end + 1
+		default:
+			buff.WriteRune(runes[idx])
+		}
+	}
+
+	quoted = buff.String()
+	return
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCompileSASS(t *testing.T) {
+	scss := `
+@import "colors";
+
+$padding: .5rem !default;
+$padding: 1rem !default;
+
+@mixin rounded($radius: 4px) {
+  border-radius: $radius;
+}
+
+.blog {
+  padding: $padding; // the inline comment
+  a, p {
+    color: $fg;
+    &:hover { color: #{$hover}; }
+  }
+  @media (max-width: 767px) {
+    display: none;
+  }
+  .code { @include rounded(1rem); content: 'x'; }
+}
+`
+	importer := func(name string) (text []byte, indented bool, err error) {
+		return []byte("$fg: #fff\n$hover: red\n"), true, nil
+	}
+
+	css, err := CompileSASS([]byte(scss), false, importer)
+	if err != nil {
+		t.Fatalf("cannot compile the SCSS: %v", err)
+	}
+
+	expect := `.blog {
+  padding: 0.5rem;
+}
+.blog a,
+.blog p {
+  color: #fff;
+}
+.blog a:hover,
+.blog p:hover {
+  color: red;
+}
+@media (max-width: 767px) {
+  .blog {
+    display: none;
+  }
+}
+.blog .code {
+  border-radius: 1rem;
+  content: "x";
+}
+`
+	if string(css) != expect {
+		t.Errorf("invalid compiled CSS:\n%v", string(css))
+	}
+
+	invalid := []string{
+		".blog { color: $missing; }",
+		".blog { @include missing; }",
+		".blog { color: red;",
+		"@import 'missing';",
+	}
+	for _, text := range invalid {
+		if _, err := CompileSASS([]byte(text), false, nil); err == nil {
+			t.Errorf("expect the error: %v", text)
+		}
+	}
+}
+
+func TestCompileSASSUnsupported(t *testing.T) {
+	for text, expect := range map[string]string{
+		"$a: 10px; .blog { width: $a*2; }":                                "line 1: unsupported SASS feature: the arithmetic $a*2",
+		"$a: 10px; .blog { width: $a / 2; }":                              "unsupported SASS feature: the arithmetic $a / 2",
+		".blog { width: 1px + 2px; }":                                     "unsupported SASS feature: the arithmetic 1px + 2px",
+		".blog { margin: 10px - 2px; }":                                   "unsupported SASS feature: the arithmetic 10px - 2px",
+		"$a: 3; .blog { z-index: $a % 2; }":                               "unsupported SASS feature: the arithmetic $a % 2",
+		"$fg: #333; .blog { color: darken($fg, 10%); }":                   "unsupported SASS feature: the function darken()",
+		"$map: (a: 1); .blog { order: map-get($map, a); }":                "unsupported SASS feature: the function map-get()",
+		"$a: 10px; .blog { width: #{$a * 2}; }":                           "unsupported SASS feature: the expression #{$a * 2}",
+		"@use 'sass:math';":                                               "unsupported SASS feature: @use",
+		"@forward 'colors';":                                              "unsupported SASS feature: @forward",
+		"@function double($a) { @return $a * 2; }":                        "unsupported SASS feature: @function",
+		"@for $i from 1 through 3 { .m-#{$i} { margin: 0; } }":            "unsupported SASS feature: @for",
+		"@each $c in red, blue { .#{$c} { color: $c; } }":                 "unsupported SASS feature: @each",
+		"@while $i > 0 { .a { color: red; } }":                            "unsupported SASS feature: @while",
+		".blog { @if $dark { color: white; } }":                           "unsupported SASS feature: @if",
+		".blog { @extend .base; }":                                        "unsupported SASS feature: @extend",
+		"@mixin box { @content; } .blog { @include box { color: red; } }": "unsupported SASS feature: @content",
+		"%base { color: red; }":                                           "unsupported SASS feature: the placeholder selector %base",
+	} {
+		if _, err := CompileSASS([]byte(text), false, nil); err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("expect %v of %v: %v", expect, text, err)
+		}
+	}
+
+	if _, err := CompileSASS([]byte("@mixin box\n  @content\n.blog\n  +box\n"), true, nil); err == nil || !strings.Contains(err.Error(), "unsupported SASS feature: @content") {
+		t.Errorf("expect the @content unsupported: %v", err)
+	}
+
+	scss := `$w: 10px;
+@font-face { unicode-range: U+0000-00FF, U+0131; src: url(a+b.woff2) format("woff2"); }
+.blog {
+  width: calc(100% - #{$w} * 2);
+  margin: 0 -$w;
+  font: 12px/1.5 "A + B", sans-serif;
+  background: -webkit-linear-gradient(top, rgba(0, 0, 0, .5), transparent);
+  --gap: $w * 2;
+}
+`
+	css, err := CompileSASS([]byte(scss), false, nil)
+	if err != nil {
+		t.Fatalf("expect the plain CSS kept: %v", err)
+	}
+	for _, expect := range []string{
+		"unicode-range: U+0000-00FF, U+0131;",
+		"src: url(a+b.woff2) format(\"woff2\");",
+		"width: calc(100% - 10px * 2);",
+		"margin: 0 -10px;",
+		`font: 12px/1.5 "A + B", sans-serif;`,
+		"background: -webkit-linear-gradient(top, rgba(0, 0, 0, 0.5), transparent);",
+		"--gap: $w * 2;",
+	} {
+		if !strings.Contains(string(css), expect) {
+			t.Errorf("expect %v in the compiled CSS:\n%s", expect, css)
+		}
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	fsys := fstest.MapFS{
+		"styles/site.sass":   {Data: []byte("@import base\n.box\n  padding: 1rem\n")},
+		"styles/_base.scss":  {Data: []byte("body { margin: 0; }")},
+		"styles/extra.css":   {Data: []byte("/* extra */\n.extra > a {\n  color: red;\n}\n")},
+		"styles/broken.scss": {Data: []byte(".a { color: $missing; }")},
+	}
+
+	render := Render{}
+	if err := yaml.Unmarshal([]byte("style: [styles/site.sass, styles/extra.css]\nminify_style: true\n"), &render); err != nil {
+		t.Fatalf("cannot load the styles: %v", err)
+	}
+	render.SetSource(fsys)
+
+	css, err := render.Stylesheet()
+	switch {
+	case err != nil:
+		t.Fatalf("cannot compile the stylesheet: %v", err)
+	case string(css) != "body{margin:0}.box{padding:1rem}.extra>a{color:red}":
+		t.Errorf("invalid stylesheet: %v", string(css))
+	}
+
+	if css := minify_stylesheet([]byte(".q::before {\n  content: \"a\\\" }  b\";\n}\n")); string(css) != `.q::before{content:"a\" }  b"}` {
+		t.Errorf("expect the escaped quote kept: %v", string(css))
+	}
+
+	render.Style = Styles{"styles/broken.scss"}
+	if _, err := render.Stylesheet(); err == nil || !strings.Contains(err.Error(), "styles/broken.scss") {
+		t.Errorf("expect the compile error: %v", err)
+	}
+
+	// the default style and the external stylesheet
+	conf := Config{}
+	dir := t.TempDir()
+	if err := conf.WriteStyle(dir); err != nil {
+		t.Fatalf("cannot write the stylesheet: %v", err)
+	}
+
+	link := conf.StyleLink()
+	if data, err := os.ReadFile(filepath.Join(dir, link)); err != nil || string(conf.CSS()) != string(data) {
+		t.Errorf("invalid external stylesheet %v: %v", link, err)
+	}
+	if !strings.Contains(string(conf.CSS()), ".box .blog table th,\n.box .blog table td {") {
+		t.Errorf("invalid default style: %v", conf.CSS())
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	_ "embed"
+)
+
+var (
+	// the JSON schema of the .gitup.yml
+	//go:embed assets/gitup.schema.json
+	SCHEMA string
+
+	// the unknown field error of the strict YAML decoding
+	RE_UNKNOWN_FIELD = regexp.MustCompile(`field (\S+) not found in type \S+`)
+)
+
+// the config key, the dotted path of the YAML field like render.brand
+type Key struct {
+	Name  string
+	Field []int
+	Type  reflect.Type
+}
+
+// list all the config keys of the config
+func Keys() (keys []Key) {
+	keys = struct_keys(reflect.TypeOf(Config{}), "", nil)
+	return
+}
+
+func struct_keys(typ reflect.Type, prefix string, index []int) (keys []Key) {
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		name := yaml_name(field)
+
+		switch {
+		case field.PkgPath != "" || name == "-":
+			// the unexported or ignored field
+			continue
+		case prefix != "":
+			name = fmt.Sprintf("%v.%v", prefix, name)
+		}
+
+		field_index := append(append([]int{}, index...), idx)
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, struct_keys(field.Type, name, field_index)...)
+		default:
+			keys = append(keys, Key{Name: name, Field: field_index, Type: field.Type})
+		}
+	}
+
+	return
+}
+
+// the YAML key of the struct field, same as the yaml.v2 which use the
+// lowercased field name by default
+func yaml_name(field reflect.StructField) (name string) {
+	tag := field.Tag.Get("yaml")
+	if idx := strings.Index(tag, ","); idx >= 0 {
+		tag = tag[:idx]
+	}
+
+	switch tag {
+	case "":
+		name = strings.ToLower(field.Name)
+	default:
+		name = tag
+	}
+	return
+}
+
+// append the suggestion of the similar key to the unknown field error
+func suggest(err error) (suggested error) {
+	suggested = err
+
+	text := err.Error()
+	matched := RE_UNKNOWN_FIELD.FindAllStringSubmatch(text, -1)
+	for _, match := range matched {
+		best, distance := "", 3
+		for _, key := range Keys() {
+			name := key.Name[strings.LastIndex(key.Name, ".")+1:]
+			if dist := levenshtein(match[1], name); dist < distance {
+				best, distance = key.Name, dist
+			}
+		}
+
+		if best != "" {
+			text = strings.Replace(text, match[0], fmt.Sprintf("%v (did you mean %v?)", match[0], best), 1)
+		}
+	}
+
+	if len(matched) > 0 {
+		suggested = fmt.Errorf("%v", text)
+	}
+	return
+}
+
+// the edit distance between two strings
+func levenshtein(x, y string) (distance int) {
+	prev := make([]int, len(y)+1)
+	curr := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(x); i++ {
+		curr[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minimum(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	distance = prev[len(y)]
+	return
+}
+
+func minimum(values ...int) (value int) {
+	value = values[0]
+	for _, v := range values[1:] {
+		if v < value {
+			value = v
+		}
+	}
+	return
+}
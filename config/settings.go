@@ -2,6 +2,7 @@ package config
 
 import (
 	"path/filepath"
+	"strings"
 
 	_ "embed"
 )
@@ -21,15 +22,47 @@ type Settings struct {
 	// the path of the license page
 	License string `yaml:"license,omitempty"`
 
+	// the extra standalone pages listed in the navigation menu
+	Pages []Page `yaml:"pages,omitempty"`
+
 	// the FavIcon of the path
 	Favicon string `yaml:"favicon,omitempty"`
 
+	// the list of the static files or folders, copied to the destination
+	// as-is with the related path
+	Assets []string `yaml:"assets,omitempty"`
+
 	// the list of the hidden posts
 	// it should be the related path in local repo
 	Hidden []string `yaml:"hidden,omitempty"`
 
 	// disabled the generated HTML file with timestamp as prefix
 	DisabledTimestampPrefix bool `yaml:"disabled_timestamp_prefix,omitempty"`
+
+	// the base URL of the published site, like https://blog.example.com/
+	BaseURL string `yaml:"base_url,omitempty"`
+
+	// generate the posts marked as draft in the front matter
+	Drafts bool `yaml:"drafts,omitempty"`
+
+	// the Google Analytics measurement ID, like G-XXXXXXXXXX
+	Analytics string `yaml:"analytics,omitempty"`
+
+	// the robots policy of the pages, like noindex, nofollow
+	Robots string `yaml:"robots,omitempty"`
+
+	// the language of the site, used to find the translations in i18n folder
+	Language string `yaml:"language,omitempty"`
+
+	// minify the HTML, CSS, JS, JSON and XML outputs
+	Minify bool `yaml:"minify,omitempty"`
+
+	// rename the static assets with the content fingerprint and rewrite the
+	// references, can be served with the long cache lifetime
+	Fingerprint bool `yaml:"fingerprint,omitempty"`
+
+	// fail the build on the link to the blog/markdown source cannot be resolved
+	Strict bool `yaml:"strict,omitempty"`
 }
 
 // return the Favicon link
@@ -54,3 +87,16 @@ func (settings Settings) IsHidden(path string) (hidden bool) {
 
 	return
 }
+
+// the absolute URL of the link joined with the base URL, the link is
+// returned as-is if the base URL is not set
+func (settings Settings) AbsURL(link string) (url string) {
+	switch settings.BaseURL {
+	case "":
+		url = link
+	default:
+		url = strings.TrimSuffix(settings.BaseURL, "/") + "/" + strings.TrimPrefix(link, "/")
+	}
+
+	return
+}
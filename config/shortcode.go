@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// the folder of the shortcode templates, like layouts/shortcodes/figure.htm
+	THEME_SHORTCODES = "shortcodes"
+	// the extension of the shortcode template
+	SHORTCODE_EXT = ".htm"
+)
+
+var (
+	// the valid name of the shortcode
+	RE_SHORTCODE_NAME = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// get the template of the shortcode, the first one found in the site
+// layouts, the theme and then the built-in ones
+func (config Config) Shortcode(name string) (tmpl *template.Template, err error) {
+	if !RE_SHORTCODE_NAME.MatchString(name) {
+		err = fmt.Errorf("invalid shortcode name: %q", name)
+		return
+	}
+
+	for _, layer := range config.layers() {
+		var data []byte
+		if data, err = fs.ReadFile(layer, path.Join(THEME_SHORTCODES, name+SHORTCODE_EXT)); err != nil {
+			// not found in this layer
+			continue
+		}
+
+		if tmpl, err = template.New(name).Funcs(config.FuncMap()).Parse(string(data)); err != nil {
+			err = fmt.Errorf("invalid shortcode %v: %v", name, err)
+		}
+		return
+	}
+
+	err = fmt.Errorf("unknown shortcode %q, available: %v", name, strings.Join(config.Shortcodes(), ", "))
+	return
+}
+
+// list the names of all the available shortcodes
+func (config Config) Shortcodes() (names []string) {
+	found := map[string]struct{}{}
+	for _, layer := range config.layers() {
+		entries, err := fs.ReadDir(layer, THEME_SHORTCODES)
+		if err != nil {
+			// no shortcode in this layer
+			continue
+		}
+
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), SHORTCODE_EXT)
+			if entry.IsDir() || path.Ext(entry.Name()) != SHORTCODE_EXT || !RE_SHORTCODE_NAME.MatchString(name) {
+				// not the shortcode template
+				continue
+			}
+
+			if _, ok := found[name]; !ok {
+				found[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdewolff/minify/v2"
+	minify_css "github.com/tdewolff/minify/v2/css"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the name of the external stylesheet, with the content fingerprint
+	STYLE_NAME = "style.%v.css"
+)
+
+// the list of the stylesheets, can be the single path or the list in YAML
+type Styles []string
+
+func (styles *Styles) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var style string
+	if err = unmarshal(&style); err == nil {
+		*styles = Styles{}
+		if style != "" {
+			*styles = Styles{style}
+		}
+		return
+	}
+
+	var list []string
+	if err = unmarshal(&list); err != nil {
+		// neither the string nor the list
+		return
+	}
+
+	*styles = Styles(list)
+	return
+}
+
+// compile the stylesheets and concatenate in order, the default style is
+// used if not set
+func (render Render) Stylesheet() (css []byte, err error) {
+	if len(render.Style) == 0 {
+		css, err = CompileSASS([]byte(TMPL_STYLE), true, nil)
+		return render.minify(css), err
+	}
+
+	var sheets []string
+	for _, style := range render.Style {
+		var sheet []byte
+		if sheet, err = render.compile(style); err != nil {
+			err = fmt.Errorf("%v: %v", style, err)
+			return
+		}
+
+		sheets = append(sheets, strings.TrimSpace(string(sheet)))
+	}
+
+	css = render.minify([]byte(strings.Join(sheets, "\n\n") + "\n"))
+	return
+}
+
+// compile the single stylesheet by the extension
+func (render Render) compile(style string) (css []byte, err error) {
+	var text []byte
+	if text, err = render.ReadFile(style); err != nil {
+		// cannot read the stylesheet
+		return
+	}
+
+	switch strings.ToLower(path.Ext(style)) {
+	case ".sass", ".scss":
+		dir := path.Dir(filepath.ToSlash(style))
+		importer := func(name string) (text []byte, indented bool, err error) {
+			return render.importSASS(dir, name)
+		}
+
+		css, err = CompileSASS(text, strings.EqualFold(path.Ext(style), ".sass"), importer)
+	default:
+		css = text
+	}
+
+	return
+}
+
+// find the imported SASS/SCSS related to the folder, the partial with the
+// underscore prefix and the file without the extension are allowed
+func (render Render) importSASS(dir, name string) (text []byte, indented bool, err error) {
+	base, file := path.Split(name)
+
+	var candidates []string
+	for _, prefix := range []string{"", "_"} {
+		switch ext := path.Ext(file); ext {
+		case ".sass", ".scss":
+			candidates = append(candidates, path.Join(dir, base, prefix+file))
+		default:
+			candidates = append(candidates, path.Join(dir, base, prefix+file+".scss"))
+			candidates = append(candidates, path.Join(dir, base, prefix+file+".sass"))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if text, err = render.ReadFile(candidate); err == nil {
+			indented = path.Ext(candidate) == ".sass"
+			return
+		}
+	}
+
+	err = fmt.Errorf("not found")
+	return
+}
+
+// minify the stylesheet if enabled
+func (render Render) minify(css []byte) (minified []byte) {
+	minified = css
+	if render.MinifyStyle {
+		minified = minify_stylesheet(css)
+	}
+	return
+}
+
+// minify the CSS by the same minifier of the post-processing, keep the
+// original if cannot minify
+func minify_stylesheet(css []byte) (minified []byte) {
+	var buff bytes.Buffer
+	if err := minify_css.Minify(minify.New(), &buff, bytes.NewReader(css), nil); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Info("cannot minify the stylesheet")
+
+		minified = css
+		return
+	}
+
+	minified = buff.Bytes()
+	return
+}
+
+// compile the stylesheet and write as the external file with the content
+// fingerprint, then the pages link to it instead of inlining
+func (config *Config) WriteStyle(dir string) (err error) {
+	var css []byte
+	if css, err = config.Stylesheet(); err != nil {
+		// cannot compile the stylesheet
+		return
+	}
+
+	if config.Minify {
+		// minify the whole site
+		css = minify_stylesheet(css)
+	}
+
+	config.style_css = template.CSS(css)
+	if config.InlineStyle {
+		// inline into every page
+		return
+	}
+
+	name := fmt.Sprintf(STYLE_NAME, Fingerprint(css))
+	dest := filepath.Join(dir, name)
+	if err = os.WriteFile(dest, css, 0640); err != nil {
+		log.WithFields(log.Fields{
+			"path":  dest,
+			"error": err,
+		}).Warn("cannot write the stylesheet")
+		return
+	}
+
+	config.style_link = name
+	return
+}
+
+// the link of the external stylesheet, empty if inlined
+func (render Render) StyleLink() (link string) {
+	link = render.style_link
+	return
+}
+
+// get the CSS style
+func (render Render) CSS() (css template.CSS) {
+	if css = render.style_css; css != "" {
+		// already compiled
+		return
+	}
+
+	data, err := render.Stylesheet()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  render.Style,
+			"error": err,
+		}).Warn("cannot compile the stylesheet")
+		return
+	}
+
+	css = template.CSS(data)
+	return
+}
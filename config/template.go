@@ -1,10 +1,9 @@
 package config
 
 import (
-	"fmt"
 	"html/template"
+	"io/fs"
 	"os"
-	"strings"
 
 	_ "embed"
 	log "github.com/sirupsen/logrus"
@@ -14,11 +13,7 @@ var (
 	// the unique key of the blog's template
 	KEY_BLOG_TMPL = "tmpl_blog_html"
 
-	//go:embed assets/blog.htm
-	TMPL_HTML string
-	//go:embed assets/list.htm
-	TMPL_LIST_HTML string
-	//go:embed assets/blog.css
+	//go:embed assets/blog.sass
 	TMPL_STYLE string
 )
 
@@ -27,68 +22,94 @@ type Render struct {
 	// the brand of the HTML page
 	Brand string
 
+	// the theme folder, or the name of the theme under the themes folder
+	Theme string `yaml:",omitempty"`
+
 	// the template of the HTML page
 	Html string `yaml:",omitempty"`
 
 	// the template of the post-list HTML page
 	ListHtmp string `yaml:",omitempty"`
 
-	// the style of the HTML page
-	Style string `yaml:",omitempty"`
+	// the stylesheets of the HTML page, the CSS, SASS or SCSS files which are
+	// compiled and concatenated in order
+	Style Styles `yaml:",omitempty"`
+
+	// minify the compiled stylesheet
+	MinifyStyle bool `yaml:"minify_style,omitempty"`
+
+	// inline the stylesheet into every page instead of the external file
+	InlineStyle bool `yaml:"inline_style,omitempty"`
+
+	// stop injecting the table of contents into the blog, the template
+	// renders it by the toc partial instead
+	DisabledInlineTOC bool `yaml:"disabled_inline_toc,omitempty"`
+
+	// the markdown parser extensions and the HTML flags, can be overridden
+	// by the front matter of the blog
+	Markdown Markdown `yaml:"markdown,omitempty"`
+
+	// the external converters of the source formats by the extension, like
+	// .adoc, only run when allowed by the command line
+	Formats map[string]Converter `yaml:"formats,omitempty"`
+
+	// the source file system of the templates, read from the local file
+	// system if not set
+	source fs.FS
+
+	style_css  template.CSS // the compiled stylesheet
+	style_link string       // the link of the external stylesheet
+}
+
+// set the source file system of the templates, like the repository
+func (render *Render) SetSource(source fs.FS) {
+	render.source = source
+}
+
+// read the file from the source file system
+func (render Render) ReadFile(path string) (data []byte, err error) {
+	switch render.source {
+	case nil:
+		data, err = os.ReadFile(path)
+	default:
+		data, err = fs.ReadFile(render.source, path)
+	}
+
+	return
 }
 
 // get the HTML template
-func (render Render) Template() (tmpl *template.Template, err error) {
+func (config Config) Template() (tmpl *template.Template, err error) {
 	var text string
-	if text, err = render.html(render.Html, TMPL_HTML); err != nil {
+	if text, err = config.layout(config.Html, THEME_BLOG); err != nil {
 		// cannot get the template text
 		return
 	}
 
-	tmpl, err = render.renderTemplate(text)
+	tmpl, err = config.renderTemplate(text)
 	return
 }
 
 // get the list/HTML template
-func (render Render) ListTemplate() (tmpl *template.Template, err error) {
+func (config Config) ListTemplate() (tmpl *template.Template, err error) {
 	var text string
-	if text, err = render.html(render.ListHtmp, TMPL_LIST_HTML); err != nil {
+	if text, err = config.layout(config.ListHtmp, THEME_LIST); err != nil {
 		// cannot get the template text
 		return
 	}
 
-	tmpl, err = render.renderTemplate(text)
+	tmpl, err = config.renderTemplate(text)
 	return
 }
 
-func (render Render) renderTemplate(text string) (tmpl *template.Template, err error) {
-	tmpl, err = template.New(KEY_BLOG_TMPL).Funcs(template.FuncMap{
-		"safe": func(text string) template.HTML {
-			return template.HTML(text)
-		},
-		"indent": func(num_indent int, text interface{}) template.HTML {
-			indent := "\n" + strings.Repeat(" ", num_indent)
-
-			switch text := text.(type) {
-			case string:
-				return template.HTML(strings.Replace(text, "\n", indent, -1))
-			case template.HTML:
-				return template.HTML(strings.Replace(string(text), "\n", indent, -1))
-			default:
-				return template.HTML(strings.Replace(fmt.Sprintf("%v", text), "\n", indent, -1))
-			}
-		},
-		"css": func(text interface{}) template.CSS {
-			switch text := text.(type) {
-			case string:
-				return template.CSS(text)
-			case template.HTML:
-				return template.CSS(text)
-			default:
-				return template.CSS(fmt.Sprintf("%v", text))
-			}
-		},
-	}).Parse(text)
+func (config Config) renderTemplate(text string) (tmpl *template.Template, err error) {
+	tmpl, err = template.New(KEY_BLOG_TMPL).Funcs(config.FuncMap()).Parse(text)
+	if err != nil {
+		// invalid template
+		return
+	}
+
+	err = config.partials(tmpl)
 	return
 }
 
@@ -100,7 +121,7 @@ func (render Render) html(filepath, default_html string) (text string, err error
 	default:
 		var data []byte
 
-		if data, err = os.ReadFile(filepath); err != nil {
+		if data, err = render.ReadFile(filepath); err != nil {
 			log.WithFields(log.Fields{
 				"path":  filepath,
 				"error": err,
@@ -112,24 +133,3 @@ func (render Render) html(filepath, default_html string) (text string, err error
 
 	return
 }
-
-// get the CSS style
-func (render Render) CSS() (css template.CSS) {
-	switch render.Style {
-	case "":
-		css = template.CSS(TMPL_STYLE)
-	default:
-		data, err := os.ReadFile(render.Html)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"path":  render.Html,
-				"error": err,
-			}).Warn("cannot read HTMP template")
-			return
-		}
-
-		css = template.CSS(data)
-	}
-
-	return
-}
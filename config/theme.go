@@ -0,0 +1,147 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the folder of the themes in the repository
+	THEME_FOLDER = "themes"
+	// the site-level folder overrides the individual theme files
+	THEME_LAYOUTS = "layouts"
+	// the folder of the shared partials, like head, nav, sidebar and footer
+	THEME_PARTIALS = "partials"
+	// the folder of the theme-provided static assets
+	THEME_STATIC = "static"
+
+	// the layout of the blog and the post-list page
+	THEME_BLOG = "blog.htm"
+	THEME_LIST = "list.htm"
+)
+
+var (
+	//go:embed assets/theme
+	theme_assets embed.FS
+
+	// the embedded default theme
+	DEFAULT_THEME, _ = fs.Sub(theme_assets, "assets/theme")
+)
+
+// the folder of the theme in the source file system, empty if use the
+// embedded default theme
+func (render Render) ThemeDir() (dir string) {
+	if render.Theme == "" {
+		// the default theme
+		return
+	}
+
+	dir = path.Clean(render.Theme)
+	if info, err := fs.Stat(render.fsys(), dir); err == nil && info.IsDir() {
+		// the theme folder
+		return
+	}
+
+	dir = path.Join(THEME_FOLDER, dir)
+	return
+}
+
+// the layers of the theme file, the site layouts, the theme and then the
+// embedded default theme
+func (render Render) layers() (layers []fs.FS) {
+	fsys := render.fsys()
+
+	if site, err := fs.Sub(fsys, THEME_LAYOUTS); err == nil {
+		layers = append(layers, site)
+	}
+
+	if dir := render.ThemeDir(); dir != "" {
+		if theme, err := fs.Sub(fsys, dir); err == nil {
+			layers = append(layers, theme)
+		}
+	}
+
+	layers = append(layers, DEFAULT_THEME)
+	return
+}
+
+// get the layout text, the explicit template path has the highest priority,
+// otherwise find the first theme file in the layers
+func (render Render) layout(filepath, name string) (text string, err error) {
+	if filepath != "" {
+		text, err = render.html(filepath, "")
+		return
+	}
+
+	for _, layer := range render.layers() {
+		var data []byte
+		if data, err = fs.ReadFile(layer, name); err == nil {
+			text = string(data)
+			return
+		}
+	}
+
+	err = fmt.Errorf("layout %v not found", name)
+	return
+}
+
+// add the partials to the template, the partial is named by the file name
+// without the extension and may override the block of the layout
+func (render Render) partials(tmpl *template.Template) (err error) {
+	layers := render.layers()
+
+	// from the lowest priority, the later one overrides the former one
+	for idx := len(layers) - 1; idx >= 0; idx-- {
+		var entries []fs.DirEntry
+		if entries, err = fs.ReadDir(layers[idx], THEME_PARTIALS); err != nil {
+			// no partial in this layer
+			err = nil
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || entry.Name()[0] == '.' {
+				// skip the folder and the hidden file
+				continue
+			}
+
+			partial := path.Join(THEME_PARTIALS, entry.Name())
+			name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+
+			var data []byte
+			if data, err = fs.ReadFile(layers[idx], partial); err != nil {
+				log.WithFields(log.Fields{
+					"path":  partial,
+					"error": err,
+				}).Warn("cannot read the partial")
+				return
+			}
+
+			if _, err = tmpl.New(name).Parse(string(data)); err != nil {
+				err = fmt.Errorf("partial %v: %v", partial, err)
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// the source file system, or the current folder if not set
+func (render Render) fsys() (fsys fs.FS) {
+	switch render.source {
+	case nil:
+		fsys = os.DirFS(".")
+	default:
+		fsys = render.source
+	}
+
+	return
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTheme(t *testing.T) {
+	fsys := fstest.MapFS{
+		"themes/minimal/list.htm":            {Data: []byte(`{{ template "nav" . }}|{{ block "list" . }}list{{ end }}|{{ template "footer" . }}`)},
+		"themes/minimal/partials/footer.htm": {Data: []byte(`theme-footer`)},
+		"themes/minimal/partials/nav.htm":    {Data: []byte(`theme-nav`)},
+		"layouts/partials/nav.htm":           {Data: []byte(`site-nav`)},
+		"layouts/partials/list.htm":          {Data: []byte(`site-list`)},
+	}
+
+	conf := Config{}
+	conf.Theme = "minimal"
+	conf.SetSource(fsys)
+
+	if dir := conf.ThemeDir(); dir != "themes/minimal" {
+		t.Fatalf("invalid theme folder: %v", dir)
+	}
+
+	tmpl, err := conf.ListTemplate()
+	if err != nil {
+		t.Fatalf("cannot get the list template: %v", err)
+	}
+
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, nil); err != nil {
+		t.Fatalf("cannot execute the list template: %v", err)
+	}
+	if text := buff.String(); text != "site-nav|site-list|theme-footer" {
+		t.Errorf("invalid theme overrides: %v", text)
+	}
+
+	// the layout not provided by the theme fallback to the default theme
+	if tmpl, err = conf.Template(); err != nil {
+		t.Fatalf("cannot get the blog template: %v", err)
+	}
+
+	buff.Reset()
+	err = tmpl.Execute(&buff, struct {
+		Config *Config
+		Blog   struct {
+			Title, Description, Author, Link, HTML string
+			CreatedAt, UpdatedAt                   time.Time
+		}
+		Description string
+		Summary     []struct{}
+		Style       string
+		UTCNow      time.Time
+	}{Config: &conf})
+	switch {
+	case err != nil:
+		t.Fatalf("cannot execute the blog template: %v", err)
+	case !strings.Contains(buff.String(), "site-nav") || !strings.Contains(buff.String(), "theme-footer"):
+		t.Errorf("expect the partials overridden: %v", buff.String())
+	}
+}
+
+func TestShortcodes(t *testing.T) {
+	conf := Config{}
+	conf.Theme = "minimal"
+	conf.SetSource(fstest.MapFS{
+		"themes/minimal/shortcodes/figure.htm": {Data: []byte(`theme-figure`)},
+		"layouts/shortcodes/badge.htm":         {Data: []byte(`{{ .Name }}`)},
+		"layouts/shortcodes/README.md":         {Data: []byte(`not the shortcode`)},
+	})
+
+	names := strings.Join(conf.Shortcodes(), ",")
+	if names != "badge,callout,figure,gist,video,youtube" {
+		t.Errorf("unexpected shortcodes: %v", names)
+	}
+
+	tmpl, err := conf.Shortcode("figure")
+	if err != nil {
+		t.Fatalf("cannot get the shortcode: %v", err)
+	}
+
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, nil); err != nil || buff.String() != "theme-figure" {
+		t.Errorf("expect the shortcode of the theme: %v %v", buff.String(), err)
+	}
+
+	for _, name := range []string{"unknown", "../figure"} {
+		if _, err := conf.Shortcode(name); err == nil {
+			t.Errorf("expect the shortcode %v fails", name)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// the list of the problems found in the config
+type ValidationError []error
+
+func (errs ValidationError) Error() (text string) {
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+
+	text = strings.Join(lines, "\n")
+	return
+}
+
+// validate the config and the referenced paths in the file system, like
+// the workdir, the about-me and the templates
+func (config *Config) Validate(fsys fs.FS) (err error) {
+	var errs ValidationError
+
+	for _, dir := range config.Workdir {
+		errs = append(errs, check_path(fsys, "workdir", dir, true)...)
+	}
+
+	errs = append(errs, check_path(fsys, "settings.abount_me", config.AboutMe, false)...)
+	errs = append(errs, check_path(fsys, "settings.license", config.License, false)...)
+	errs = append(errs, check_path(fsys, "settings.favicon", config.Favicon, false)...)
+	for idx, page := range config.Pages {
+		key := fmt.Sprintf("settings.pages[%d]", idx)
+		if err := page.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", key, err))
+			continue
+		}
+
+		errs = append(errs, check_path(fsys, key+".source", page.Source, false)...)
+	}
+	outputs := map[string]struct{}{}
+	for _, page := range config.NavPages() {
+		if _, ok := outputs[page.Link()]; ok {
+			errs = append(errs, fmt.Errorf("settings.pages: duplicate output name: %v", page.Link()))
+		}
+		outputs[page.Link()] = struct{}{}
+	}
+	for _, asset := range config.Assets {
+		if errs_asset := check_path(fsys, "settings.assets", asset, false); len(errs_asset) > 0 {
+			// the asset may be the folder
+			errs = append(errs, check_path(fsys, "settings.assets", asset, true)...)
+		}
+	}
+	for _, hidden := range config.Hidden {
+		errs = append(errs, check_path(fsys, "settings.hidden", hidden, false)...)
+	}
+	errs = append(errs, config.check_profiles()...)
+
+	local := *config
+	local.SetSource(fsys)
+	render := local.Render
+	if dir := render.ThemeDir(); dir != "" {
+		errs = append(errs, check_path(fsys, "render.theme", dir, true)...)
+	}
+
+	templates := [][3]string{
+		{"render.html", render.Html, THEME_BLOG},
+		{"render.listhtmp", render.ListHtmp, THEME_LIST},
+	}
+	for _, pair := range templates {
+		key, tmpl, name := pair[0], pair[1], pair[2]
+		if errs_tmpl := check_path(fsys, key, tmpl, false); len(errs_tmpl) > 0 {
+			errs = append(errs, errs_tmpl...)
+			continue
+		}
+
+		text, _ := render.layout(tmpl, name) // nolint
+		if tmpl == "" {
+			// the layout of the theme
+			key, tmpl = "render.theme", name
+		}
+
+		if _, err := local.renderTemplate(text); err != nil {
+			errs = append(errs, fmt.Errorf("%v: invalid template %v: %v", key, tmpl, err))
+		}
+	}
+	for _, style := range render.Style {
+		errs = append(errs, check_path(fsys, "render.style", style, false)...)
+	}
+	if _, err := render.Stylesheet(); err != nil {
+		errs = append(errs, fmt.Errorf("render.style: %v", err))
+	}
+	exts := make([]string, 0, len(render.Formats))
+	for ext := range render.Formats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		switch {
+		case !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext[1:], "./") || len(ext) == 1:
+			errs = append(errs, fmt.Errorf("render.formats: invalid extension: %v", ext))
+		default:
+			if err := render.Formats[ext].validate(); err != nil {
+				errs = append(errs, fmt.Errorf("render.formats.%v: %v", ext, err))
+			}
+		}
+	}
+	for idx, page := range config.Pages {
+		if page.Layout == "" {
+			// the blog layout
+			continue
+		}
+
+		if _, err := local.LayoutTemplate(page.Layout); err != nil {
+			errs = append(errs, fmt.Errorf("settings.pages[%d].layout: %v", idx, err))
+		}
+	}
+
+	for idx, source := range config.Sources {
+		switch uri, err := url.Parse(source.Repo); {
+		case source.Repo == "":
+			errs = append(errs, fmt.Errorf("sources[%d].repo: the repository is required", idx))
+		case err != nil:
+			errs = append(errs, fmt.Errorf("sources[%d].repo: %v", idx, err))
+		case uri.Scheme == "":
+			errs = append(errs, fmt.Errorf("sources[%d].repo: missing scheme: %v", idx, source.Repo))
+		}
+	}
+
+	if len(errs) > 0 {
+		err = errs
+	}
+	return
+}
+
+// check the path exists in the file system, and should be the folder or
+// the file
+func check_path(fsys fs.FS, key, name string, dir bool) (errs []error) {
+	if name == "" {
+		// not set
+		return
+	}
+
+	clean := path.Clean(name)
+	if !fs.ValidPath(clean) {
+		errs = append(errs, fmt.Errorf("%v: invalid path outside the repository: %v", key, name))
+		return
+	}
+
+	info, err := fs.Stat(fsys, clean)
+	switch {
+	case err != nil:
+		errs = append(errs, fmt.Errorf("%v: %v not found", key, name))
+	case dir && !info.IsDir():
+		errs = append(errs, fmt.Errorf("%v: %v is not the folder", key, name))
+	case !dir && info.IsDir():
+		errs = append(errs, fmt.Errorf("%v: %v is not the file", key, name))
+	}
+
+	return
+}
@@ -3,10 +3,14 @@ package gitup
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/check"
 	"github.com/cmj0121/gitup/clone"
 	"github.com/cmj0121/gitup/config"
+	"github.com/cmj0121/gitup/lint"
+	"github.com/cmj0121/gitup/scaffold"
 )
 
 type version bool
@@ -17,15 +21,86 @@ func (ver version) BeforeApply() (err error) {
 	return
 }
 
-// the dummy struct to dump the config
-type conf_render struct{}
+// the sub-commands of the config
+type conf_command struct {
+	Dump   *conf_render `cmd:"" default:"withargs" help:"dump the config settings"`
+	Check  *conf_check  `cmd:"" help:"validate the config and the referenced paths of the repository"`
+	Schema *conf_schema `cmd:"" help:"show the JSON schema of the .gitup.yml"`
+}
+
+// dump the config merged with the repository config
+type conf_render struct {
+	Path string `arg:"" optional:"" default:"." type:"existingdir" help:"the folder of the repository"`
+
+	// explain every effective value and where it comes from
+	Explain bool `help:"show every effective value and where it comes from"`
+}
 
 // show the config as YAML format
-func (*conf_render) Run(conf *config.Config) (err error) {
-	fmt.Println(conf)
+func (render *conf_render) Run(conf *config.Config) (err error) {
+	if err = conf.LoadFS(os.DirFS(filepath.Clean(render.Path)), "."); err != nil {
+		// invalid config in the repository
+		return
+	}
+
+	if err = conf.CheckProfile(); err != nil {
+		// the profile is not defined
+		return
+	}
+
+	switch render.Explain {
+	case true:
+		fmt.Println(conf.Explain())
+	case false:
+		fmt.Println(conf)
+	}
 	return
 }
 
+// validate the config of the repository
+type conf_check struct {
+	Path string `arg:"" optional:"" default:"." type:"existingdir" help:"the folder of the repository"`
+}
+
+// load the config strictly and validate, exit with non-zero if any problem
+func (check *conf_check) Run(conf *config.Config) (err error) {
+	fsys := os.DirFS(filepath.Clean(check.Path))
+
+	if err = conf.LoadFS(fsys, "."); err != nil {
+		// invalid YAML or unknown key
+		return
+	}
+
+	if err = conf.CheckProfile(); err != nil {
+		// the profile is not defined
+		return
+	}
+
+	if err = conf.Validate(fsys); err != nil {
+		// show all the problems
+		fmt.Fprintln(os.Stderr, err)
+		err = fmt.Errorf("invalid config in %v", check.Path)
+		return
+	}
+
+	fmt.Println("config OK")
+	return
+}
+
+// the dummy struct to show the JSON schema
+type conf_schema struct{}
+
+// show the JSON schema of the config
+func (*conf_schema) Run(conf *config.Config) (err error) {
+	fmt.Print(config.SCHEMA)
+	return
+}
+
+// the sub-commands to check the generated webpage
+type check_command struct {
+	Links *check.Links `cmd:"" help:"check the internal and external links of the generated webpage"`
+}
+
 // the command-line interface of GitUp
 type CLI struct {
 	// show the version info
@@ -38,8 +113,15 @@ type CLI struct {
 	LogFile string `type:"path" name:"log-file" help:"the log file destination (default: STDERR)"`
 
 	// the sub-command and config settings
-	Settings string       `short:"s" name:"setting" type:"file" help:"the global settings of the gitup"`
-	Blog     *blog.Blog   `cmd:"" help:"generate the HTML by single blog/markdown"`
-	Clone    *clone.Clone `cmd:"" help:"clone the repository and generate HTML webpages"`
-	Config   *conf_render `name:"config" cmd:"" help:"dump the config settings"`
+	Settings  string           `short:"s" name:"setting" type:"file" help:"the global settings of the gitup"`
+	Set       []string         `name:"set" sep:"none" placeholder:"KEY=VALUE" help:"override the config key, like render.brand=Blog"`
+	Profile   string           `env:"GITUP_PROFILE" help:"the profile overlays the config, like staging or production"`
+	Blog      *blog.Blog       `cmd:"" help:"generate the HTML by single blog/markdown"`
+	Clone     *clone.Clone     `cmd:"" help:"clone the repository and generate HTML webpages"`
+	Aggregate *clone.Aggregate `cmd:"" help:"aggregate several repositories into one site"`
+	Config    *conf_command    `name:"config" cmd:"" help:"dump or check the config settings"`
+	Check     *check_command   `cmd:"" help:"check the generated webpage"`
+	Lint      *lint.Lint       `cmd:"" help:"lint the blogs/markdown in the workdir before publishing"`
+	Init      *scaffold.Init   `cmd:"" help:"create the ready-to-build skeleton of the blog repository"`
+	New       *scaffold.Post   `cmd:"" help:"create the new blog/markdown with the front matter"`
 }
@@ -1,8 +1,10 @@
 package gitup
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kong"
 	"github.com/cmj0121/gitup/config"
@@ -44,7 +46,7 @@ func (gitup *GitUp) Run() {
 
 	if err := gitup.prologue(); err != nil {
 		// cannot run prepare steps
-		panic(err)
+		ctx.FatalIfErrorf(err)
 	}
 	defer gitup.epilogue()
 
@@ -83,12 +85,43 @@ func (gitup *GitUp) prologue() (err error) {
 
 	log.Trace("setup the log sub-system")
 
+	// the layered config: defaults, the user config, the external settings,
+	// the repository config, the environment variables and the --set flags,
+	// and the active profile overlays the config which defines it
+	gitup.Config.UseProfile(gitup.CLI.Profile)
+	if err = gitup.Config.LoadUser(); err != nil {
+		// invalid user config
+		return
+	}
+
 	if gitup.CLI.Settings != "" {
 		// load external settings
 		log.WithFields(log.Fields{
 			"settings": gitup.CLI.Settings,
 		}).Debug("load external config")
-		gitup.Config.Load(gitup.CLI.Settings)
+
+		if err = gitup.Config.Load(gitup.CLI.Settings); err != nil {
+			// invalid external settings
+			return
+		}
+	}
+
+	if err = gitup.Config.LoadEnv(os.Environ()); err != nil {
+		// invalid environment variables
+		return
+	}
+
+	for _, set := range gitup.CLI.Set {
+		idx := strings.Index(set, "=")
+		if idx < 0 {
+			err = fmt.Errorf("invalid --set %v, should be key=value", set)
+			return
+		}
+
+		if err = gitup.Config.Set(set[:idx], set[idx+1:], "--set"); err != nil {
+			// invalid key or value
+			return
+		}
 	}
 	return
 }
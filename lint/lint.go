@@ -0,0 +1,210 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the SARIF version and schema
+	SARIF_VERSION = "2.1.0"
+	SARIF_SCHEMA  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// check all the blogs/markdown in the workdir before publishing
+type Lint struct {
+	// the folder of the repository
+	Path string `arg:"" optional:"" default:"." type:"existingdir" help:"the folder of the repository"`
+
+	// the output format and the extra disabled rules
+	Format  string   `short:"f" enum:"human,json,sarif" default:"human" help:"the output format (human, json, sarif)"`
+	Disable []string `placeholder:"RULE" help:"disable the rule, like long-line"`
+
+	includer blog.Includer // read the included files, from the fsys if not set
+}
+
+// lint the posts and exit with non-zero if any error found
+func (lint *Lint) Run(conf *config.Config) (err error) {
+	fsys := os.DirFS(filepath.Clean(lint.Path))
+	lint.includer = blog.DirIncluder(filepath.Clean(lint.Path))
+
+	conf.SetSource(fsys)
+	if err = conf.LoadFS(fsys, "."); err != nil {
+		// invalid config in the repository
+		return
+	}
+
+	if err = conf.CheckProfile(); err != nil {
+		// the profile is not defined
+		return
+	}
+
+	var findings []Finding
+	if findings, err = lint.Check(conf, fsys); err != nil {
+		// cannot lint the posts
+		return
+	}
+
+	if err = lint.Report(os.Stdout, findings); err != nil {
+		// cannot write the report
+		return
+	}
+
+	errors := 0
+	for _, finding := range findings {
+		if finding.Severity == SEVERITY_ERROR {
+			errors++
+		}
+	}
+
+	if errors > 0 {
+		err = fmt.Errorf("%d error(s) found in %v", errors, lint.Path)
+		return
+	}
+	return
+}
+
+// check all the blogs/markdown in the workdir, in the workdir and name order
+func (lint *Lint) Check(conf *config.Config, fsys fs.FS) (findings []Finding, err error) {
+	rules := conf.Lint
+	rules.Disabled = append(append([]string{}, rules.Disabled...), lint.Disable...)
+
+	var c *checker
+	if c, err = new_checker(rules); err != nil {
+		// invalid rule settings
+		return
+	}
+	c.site = conf
+	if c.includer = lint.includer; c.includer == nil {
+		c.includer = blog.FSIncluder(fsys)
+	}
+
+	var files []string
+	for _, dir := range conf.Workdir {
+		dir = filepath.ToSlash(filepath.Clean(dir))
+		if !fs.ValidPath(dir) {
+			err = fmt.Errorf("invalid folder path: %v", dir)
+			return
+		}
+
+		var entries []fs.DirEntry
+		if entries, err = fs.ReadDir(fsys, dir); err != nil {
+			log.WithFields(log.Fields{
+				"path":  dir,
+				"error": err,
+			}).Warn("cannot list blog")
+			return
+		}
+
+		for _, entry := range entries {
+			switch name := entry.Name(); {
+			case name[0] == '.', entry.IsDir():
+				// the hidden file or the folder, skip
+			case strings.HasSuffix(name, ".md"), strings.HasSuffix(name, ".markdown"):
+				files = append(files, path.Join(dir, name))
+			}
+		}
+	}
+
+	for _, file := range files {
+		var text []byte
+		if text, err = fs.ReadFile(fsys, file); err != nil {
+			// cannot read the blog/markdown
+			return
+		}
+
+		c.check(file, text)
+	}
+
+	findings = c.findings
+	return
+}
+
+// write the findings in the output format
+func (lint *Lint) Report(w io.Writer, findings []Finding) (err error) {
+	switch lint.Format {
+	case "json":
+		if findings == nil {
+			findings = []Finding{}
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(findings)
+	case "sarif":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(sarif(findings))
+	default:
+		for _, finding := range findings {
+			if _, err = fmt.Fprintln(w, finding); err != nil {
+				// cannot write the finding
+				return
+			}
+		}
+
+		if len(findings) == 0 {
+			_, err = fmt.Fprintln(w, "lint OK")
+		}
+	}
+
+	return
+}
+
+// the SARIF log of the findings, for the code scanning
+func sarif(findings []Finding) (report map[string]interface{}) {
+	rules := []map[string]interface{}{}
+	for _, rule := range RULES {
+		rules = append(rules, map[string]interface{}{
+			"id":                   rule.ID,
+			"shortDescription":     map[string]string{"text": rule.Description},
+			"defaultConfiguration": map[string]string{"level": rule.Severity},
+		})
+	}
+
+	results := []map[string]interface{}{}
+	for _, finding := range findings {
+		results = append(results, map[string]interface{}{
+			"ruleId":  finding.Rule,
+			"level":   finding.Severity,
+			"message": map[string]string{"text": finding.Message},
+			"locations": []map[string]interface{}{
+				{
+					"physicalLocation": map[string]interface{}{
+						"artifactLocation": map[string]string{"uri": finding.Path},
+						"region":           map[string]int{"startLine": finding.Line},
+					},
+				},
+			},
+		})
+	}
+
+	report = map[string]interface{}{
+		"version": SARIF_VERSION,
+		"$schema": SARIF_SCHEMA,
+		"runs": []map[string]interface{}{
+			{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "gitup",
+						"informationUri": "https://github.com/cmj0121/gitup",
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestLint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/good.md":      {Data: []byte("# Good\n> the good post\n\n## Section\n\n![logo](logo.png)\n")},
+		"posts/no-title.md":  {Data: []byte("---\ndescription: from the front matter\n---\nno title\n\n![](logo.png) <img src=\"logo.png\">\n")},
+		"posts/jump.md":      {Data: []byte("# Jump\n\n#### Deep\n\n```\n# not a heading\n" + strings.Repeat("x", 200) + "\n```\n" + strings.Repeat("y", 121) + "\n")},
+		"posts/broken.md":    {Data: []byte("---\ntitle: [broken\n---\n# Broken\n")},
+		"posts/shortcode.md": {Data: []byte("# Shortcode\n> the unknown shortcode\n\n{{< unknown >}}\n")},
+		"drafts/good.md":     {Data: []byte("# Good\n> the same title and slug\n")},
+		"drafts/unclosed.md": {Data: []byte("---\ntitle: unclosed\n# Unclosed\n> the unclosed front matter\n")},
+	}
+
+	conf := &config.Config{Workdir: []string{"posts", "drafts"}}
+	conf.DisabledTimestampPrefix = true
+	conf.Lint.Severity = map[string]string{"long-line": "warning"}
+
+	lint := &Lint{Disable: []string{"missing-description"}}
+	findings, err := lint.Check(conf, fsys)
+	if err != nil {
+		t.Fatalf("cannot lint: %v", err)
+	}
+
+	var found []string
+	for _, finding := range findings {
+		found = append(found, finding.String())
+	}
+
+	expect := []string{
+		"posts/broken.md:2: error: invalid front matter: yaml: line 1: did not find expected ',' or ']' [front-matter]",
+		"posts/jump.md:3: warning: the heading level jumps from h1 to h4 [heading-jump]",
+		"posts/jump.md:9: warning: the line has 121 characters, longer than 120 [long-line]",
+		"posts/no-title.md:4: error: no <h1> title or the title in the front matter [missing-title]",
+		"posts/no-title.md:6: warning: the image without the alt text [image-alt]",
+		"posts/no-title.md:6: warning: the <img> without the alt text [image-alt]",
+		"posts/shortcode.md:4: error: unknown shortcode \"unknown\", available: callout, figure, gist, video, youtube [shortcode]",
+		"drafts/good.md:1: warning: the title \"Good\" is also used by posts/good.md [duplicate-title]",
+		"drafts/good.md:1: warning: the output name good is also used by posts/good.md, renamed to good-2 [duplicate-slug]",
+		"drafts/unclosed.md:1: error: the front matter is not closed [front-matter]",
+	}
+
+	if strings.Join(found, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expect the findings:\n%v\nbut got:\n%v", strings.Join(expect, "\n"), strings.Join(found, "\n"))
+	}
+
+	var buff bytes.Buffer
+	lint.Format = "sarif"
+	if err := lint.Report(&buff, findings); err != nil {
+		t.Fatalf("cannot write the SARIF: %v", err)
+	}
+
+	var report struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID string
+				Level  string
+			}
+		}
+	}
+	if err := json.Unmarshal(buff.Bytes(), &report); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if report.Version != SARIF_VERSION || len(report.Runs) != 1 || len(report.Runs[0].Results) != len(expect) {
+		t.Errorf("unexpected SARIF: %v", buff.String())
+	}
+
+	conf.DisabledTimestampPrefix = false
+	if findings, _ := lint.Check(conf, fsys); len(findings) != len(expect)-1 {
+		t.Errorf("expect the name unique by the timestamp prefix: %v", findings)
+	}
+
+	conf.Lint.Disabled = []string{"unknown-rule"}
+	if _, err := lint.Check(conf, fsys); err == nil {
+		t.Errorf("expect the unknown rule fails")
+	}
+}
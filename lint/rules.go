@@ -0,0 +1,287 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+)
+
+const (
+	// the severity of the finding, same as the SARIF level
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+	SEVERITY_NOTE    = "note"
+)
+
+// the lint rule of the blog/markdown
+type Rule struct {
+	ID          string
+	Severity    string
+	Description string
+}
+
+var (
+	// all the lint rules
+	RULES = []Rule{
+		{"front-matter", SEVERITY_ERROR, "the front matter is invalid or not closed"},
+		{"missing-title", SEVERITY_ERROR, "no <h1> title or the title in the front matter"},
+		{"missing-description", SEVERITY_WARNING, "no description blockquote or the description in the front matter"},
+		{"duplicate-title", SEVERITY_WARNING, "the title is used by the other post"},
+		{"duplicate-slug", SEVERITY_WARNING, "the output name is used by the other post and renamed"},
+		{"heading-jump", SEVERITY_WARNING, "the heading level increases by more than one"},
+		{"image-alt", SEVERITY_WARNING, "the image without the alt text"},
+		{"long-line", SEVERITY_NOTE, "the line is longer than the max_line_length"},
+		{"shortcode", SEVERITY_ERROR, "the shortcode is unknown or invalid"},
+	}
+
+	// the ATX heading, the fenced code block and the images
+	RE_HEADING     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s|$)`)
+	RE_FENCE       = regexp.MustCompile("^ {0,3}(```|~~~)")
+	RE_IMAGE_EMPTY = regexp.MustCompile(`!\[\s*\]\(`)
+	RE_IMG_TAG     = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	RE_IMG_ALT     = regexp.MustCompile(`(?i)\salt\s*=\s*("[^"]*[^"\s][^"]*"|'[^']*[^'\s][^']*'|[^\s"'>]+)`)
+	// the line number in the YAML error
+	RE_YAML_LINE = regexp.MustCompile(`line (\d+)`)
+)
+
+// the problem found in the blog/markdown
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// show as path:line: severity: message [rule]
+func (finding Finding) String() (str string) {
+	str = fmt.Sprintf("%v:%d: %v: %v [%v]", finding.Path, finding.Line, finding.Severity, finding.Message, finding.Rule)
+	return
+}
+
+// the checker of the rules across the posts
+type checker struct {
+	conf     config.Lint
+	site     *config.Config // the config of the rendering, optional
+	includer blog.Includer  // read the included files of the repository, optional
+	findings []Finding
+
+	titles map[string]string // the title to the first post
+	slugs  map[string]string // the output name to the first post
+}
+
+// create the checker with the rule settings
+func new_checker(conf config.Lint) (c *checker, err error) {
+	for _, name := range conf.Disabled {
+		if _, ok := find_rule(name); !ok {
+			err = fmt.Errorf("unknown lint rule: %v", name)
+			return
+		}
+	}
+
+	for name, severity := range conf.Severity {
+		if _, ok := find_rule(name); !ok {
+			err = fmt.Errorf("unknown lint rule: %v", name)
+			return
+		}
+
+		switch severity {
+		case SEVERITY_ERROR, SEVERITY_WARNING, SEVERITY_NOTE:
+		default:
+			err = fmt.Errorf("invalid severity of %v: %v", name, severity)
+			return
+		}
+	}
+
+	c = &checker{
+		conf:   conf,
+		titles: map[string]string{},
+		slugs:  map[string]string{},
+	}
+	return
+}
+
+// record the finding if the rule is enabled
+func (c *checker) report(rule, file string, line int, format string, args ...interface{}) {
+	if c.conf.IsDisabled(rule) {
+		// the disabled rule
+		return
+	}
+
+	r, _ := find_rule(rule)
+	severity := r.Severity
+	if custom, ok := c.conf.Severity[rule]; ok {
+		severity = custom
+	}
+
+	c.findings = append(c.findings, Finding{
+		Rule:     rule,
+		Severity: severity,
+		Path:     file,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// check the single blog/markdown
+func (c *checker) check(file string, text []byte) {
+	meta, md, err := blog.SplitFrontMatter(text)
+	if err != nil {
+		line := 1
+		if matched := RE_YAML_LINE.FindStringSubmatch(err.Error()); matched != nil {
+			// the YAML starts after the delimiter
+			n, _ := strconv.Atoi(matched[1]) // nolint
+			line += n
+		}
+
+		c.report("front-matter", file, line, "%v", err)
+		return
+	}
+
+	head := bytes.TrimPrefix(text, []byte("\ufeff"))
+	if len(md) == len(text) && bytes.HasPrefix(head, blog.FRONT_MATTER_DELIMITER) {
+		if first := bytes.SplitN(head, []byte("\n"), 2)[0]; bytes.Equal(bytes.TrimSpace(first), blog.FRONT_MATTER_DELIMITER) {
+			c.report("front-matter", file, 1, "the front matter is not closed")
+		}
+	}
+
+	// the line offset of the markdown after the front matter
+	offset := bytes.Count(text[:len(text)-len(md)], []byte("\n"))
+
+	md_blog, err := blog.New(bytes.NewReader(text))
+	if err == nil {
+		md_blog.Path = file
+		md_blog.SetConfig(c.site)
+		md_blog.SetIncluder(c.includer)
+		_, err = md_blog.RenderHTML()
+	}
+
+	var shortcode blog.ShortcodeError
+	switch {
+	case errors.As(err, &shortcode):
+		c.report("shortcode", file, shortcode.Line, "%v", shortcode.Err)
+		return
+	case err != nil:
+		c.report("front-matter", file, 1, "%v", err)
+		return
+	}
+
+	switch first, ok := c.titles[md_blog.Title]; {
+	case md_blog.Title == "":
+		c.report("missing-title", file, offset+1, "no <h1> title or the title in the front matter")
+	case ok:
+		c.report("duplicate-title", file, offset+1, "the title %q is also used by %v", md_blog.Title, first)
+	default:
+		c.titles[md_blog.Title] = file
+	}
+
+	if md_blog.Description == "" && meta.Description == "" {
+		c.report("missing-description", file, offset+1, "no description blockquote or the description in the front matter")
+	}
+
+	c.check_name(file)
+
+	c.check_lines(file, md, offset)
+}
+
+// check the output name as the clone generates, the later one is renamed with
+// the -2, -3 ... suffix, and the name with the timestamp prefix is unique
+func (c *checker) check_name(file string) {
+	site := c.site
+	if site == nil {
+		// the default settings
+		site = &config.Config{}
+	}
+
+	if !site.IsHidden(file) && !site.DisabledTimestampPrefix {
+		// unique by the timestamp prefix
+		return
+	}
+
+	for _, name := range site.ReservedNames() {
+		if _, ok := c.slugs[name]; !ok {
+			c.slugs[name] = name + config.PAGE_SUFFIX
+		}
+	}
+
+	name := (&blog.Blog{Path: file}).Name(site)
+	first, ok := c.slugs[name]
+	if !ok {
+		c.slugs[name] = file
+		return
+	}
+
+	unique := name
+	for idx := 2; ; idx++ {
+		if _, ok := c.slugs[unique]; !ok {
+			break
+		}
+
+		unique = fmt.Sprintf("%v-%d", name, idx)
+	}
+	c.slugs[unique] = file
+
+	c.report("duplicate-slug", file, 1, "the output name %v is also used by %v, renamed to %v", name, first, unique)
+}
+
+// check the rules line by line, skip the fenced code block
+func (c *checker) check_lines(file string, md []byte, offset int) {
+	fence, level := "", 0
+	for idx, line := range strings.Split(string(md), "\n") {
+		lineno := offset + idx + 1
+		line = strings.TrimRight(line, "\r")
+
+		if matched := RE_FENCE.FindStringSubmatch(line); matched != nil {
+			switch fence {
+			case "":
+				fence = matched[1]
+			case matched[1]:
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			// in the fenced code block
+			continue
+		}
+
+		if matched := RE_HEADING.FindStringSubmatch(line); matched != nil {
+			heading := len(matched[1])
+			if level > 0 && heading > level+1 {
+				c.report("heading-jump", file, lineno, "the heading level jumps from h%d to h%d", level, heading)
+			}
+			level = heading
+		}
+
+		if RE_IMAGE_EMPTY.MatchString(line) {
+			c.report("image-alt", file, lineno, "the image without the alt text")
+		}
+		for _, tag := range RE_IMG_TAG.FindAllString(line, -1) {
+			if !RE_IMG_ALT.MatchString(tag) {
+				c.report("image-alt", file, lineno, "the <img> without the alt text")
+			}
+		}
+
+		if length := utf8.RuneCountInString(line); length > c.conf.LineLength() && !strings.HasPrefix(strings.TrimSpace(line), "|") {
+			c.report("long-line", file, lineno, "the line has %d characters, longer than %d", length, c.conf.LineLength())
+		}
+	}
+}
+
+// find the rule by ID
+func find_rule(id string) (rule Rule, ok bool) {
+	for _, rule = range RULES {
+		if rule.ID == id {
+			ok = true
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the name of the copied default theme
+	THEME_DEFAULT = "default"
+	// the first post of the new blog
+	FIRST_POST = "Hello World"
+)
+
+var (
+	//go:embed assets/skeleton
+	skeleton_assets embed.FS
+
+	// the embedded skeleton of the new blog repository, the files are the
+	// templates and the gitup.yml is written as the .gitup.yml
+	SKELETON, _ = fs.Sub(skeleton_assets, "assets/skeleton")
+)
+
+// create the ready-to-build skeleton of the blog repository
+type Init struct {
+	// the folder of the new blog repository
+	Path string `arg:"" optional:"" default:"." type:"path" help:"the folder of the new blog repository"`
+
+	// the brand of the blog, the folder name by default
+	Brand string `help:"the brand of the blog (default: the folder name)"`
+
+	// copy the default theme into the repository for customising
+	Theme bool `help:"copy the default theme into themes/default for customising"`
+
+	// overwrite the existing files
+	Force bool `help:"overwrite the existing files"`
+}
+
+// create the skeleton and the first post
+func (repo *Init) Run(conf *config.Config) (err error) {
+	root := filepath.Clean(repo.Path)
+
+	if err = repo.Create(root, time.Now()); err != nil {
+		// cannot create the skeleton
+		return
+	}
+
+	fmt.Printf("the new blog is created in %v\n", root)
+	return
+}
+
+// create the skeleton in the folder, never overwrite the existing file unless
+// forced
+func (repo *Init) Create(root string, now time.Time) (err error) {
+	brand := repo.Brand
+	if brand == "" {
+		brand = filepath.Base(abs(root))
+	}
+
+	theme := ""
+	if repo.Theme {
+		theme = THEME_DEFAULT
+	}
+
+	data := struct {
+		Brand string
+		Theme string
+		Year  int
+	}{
+		Brand: brand,
+		Theme: theme,
+		Year:  now.Year(),
+	}
+
+	files := map[string][]byte{}
+	err = fs.WalkDir(SKELETON, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		text, err := fs.ReadFile(SKELETON, name)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return err
+		}
+
+		var buff bytes.Buffer
+		if err := tmpl.Execute(&buff, data); err != nil {
+			return err
+		}
+
+		if name == "gitup.yml" {
+			// the hidden config cannot be embedded
+			name = config.ConfigPath[0]
+		}
+		files[name] = buff.Bytes()
+		return nil
+	})
+	if err != nil {
+		// cannot render the skeleton
+		return
+	}
+
+	files[path.Join(ARCHETYPE_FOLDER, ARCHETYPE_DEFAULT)] = []byte(DEFAULT_ARCHETYPE)
+	if repo.Theme {
+		err = fs.WalkDir(config.DEFAULT_THEME, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			text, err := fs.ReadFile(config.DEFAULT_THEME, name)
+			files[path.Join(config.THEME_FOLDER, THEME_DEFAULT, name)] = text
+			return err
+		})
+		if err != nil {
+			// cannot copy the default theme
+			return
+		}
+	}
+
+	var existed []string
+	for name := range files {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err == nil {
+			existed = append(existed, name)
+		}
+	}
+	sort.Strings(existed)
+	if len(existed) > 0 && !repo.Force {
+		err = fmt.Errorf("already exists, overwrite by --force: %v", strings.Join(existed, ", "))
+		return
+	}
+
+	for name, text := range files {
+		dest := filepath.Join(root, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+			// cannot create the parent folder
+			return
+		}
+
+		if err = os.WriteFile(dest, text, 0640); err != nil {
+			log.WithFields(log.Fields{
+				"path":  dest,
+				"error": err,
+			}).Warn("cannot write the skeleton")
+			return
+		}
+	}
+
+	conf := &config.Config{}
+	if err = conf.LoadFS(os.DirFS(root), "."); err != nil {
+		// invalid generated config
+		return
+	}
+
+	if entries, _ := os.ReadDir(filepath.Join(root, conf.Workdir[0])); len(entries) > 0 {
+		// keep the existing posts
+		return
+	}
+
+	post := &Post{Title: FIRST_POST}
+	_, err = post.Create(conf, root, now)
+	return
+}
+
+// the absolute path, or the original one if cannot resolve
+func abs(path string) (dir string) {
+	var err error
+	if dir, err = filepath.Abs(path); err != nil {
+		dir = path
+	}
+	return
+}
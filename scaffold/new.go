@@ -0,0 +1,157 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/cmj0121/gitup/config"
+
+	_ "embed"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the folder of the archetypes in the repository
+	ARCHETYPE_FOLDER = "archetypes"
+	// the default archetype
+	ARCHETYPE_DEFAULT = "default.md"
+)
+
+var (
+	// the embedded archetype used when the repository has no default one
+	//go:embed assets/archetype.md
+	DEFAULT_ARCHETYPE string
+)
+
+// create the new blog/markdown with the front matter
+type Post struct {
+	// the title of the new post
+	Title string `arg:"" help:"the title of the new post"`
+
+	// the folder of the repository and the workdir of the new post
+	Path    string `default:"." type:"existingdir" help:"the folder of the repository"`
+	Workdir string `short:"w" help:"the workdir of the new post (default: the first workdir)"`
+
+	// the archetype, the template of the new post in the archetypes folder
+	Archetype string `short:"a" help:"the archetype in the archetypes folder (default: default.md if exists)"`
+
+	// mark the new post as draft
+	Draft bool `help:"mark the new post as draft in the front matter"`
+}
+
+// create the new post in the workdir of the repository
+func (post *Post) Run(conf *config.Config) (err error) {
+	root := filepath.Clean(post.Path)
+	if err = conf.LoadFS(os.DirFS(root), "."); err != nil {
+		// invalid config in the repository
+		return
+	}
+
+	var path string
+	if path, err = post.Create(conf, root, time.Now()); err != nil {
+		// cannot create the new post
+		return
+	}
+
+	fmt.Println(path)
+	return
+}
+
+// create the new post and return the path, never overwrite the existing one
+func (post *Post) Create(conf *config.Config, root string, now time.Time) (path string, err error) {
+	workdir := post.Workdir
+	switch {
+	case workdir != "":
+	case len(conf.Workdir) > 0:
+		workdir = conf.Workdir[0]
+	default:
+		err = fmt.Errorf("no workdir in the config, set by --workdir")
+		return
+	}
+
+	slug := config.Slugify(post.Title)
+	if slug == "" {
+		err = fmt.Errorf("cannot slugify the title: %q", post.Title)
+		return
+	}
+
+	path = filepath.Join(root, filepath.Clean(workdir), slug+".md")
+	if _, err = os.Stat(path); err == nil {
+		err = fmt.Errorf("%v already exists", path)
+		return
+	}
+
+	var text []byte
+	if text, err = post.render(root, slug, now); err != nil {
+		// cannot render the archetype
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		// cannot create the workdir
+		return
+	}
+
+	if err = os.WriteFile(path, text, 0640); err != nil {
+		log.WithFields(log.Fields{
+			"path":  path,
+			"error": err,
+		}).Warn("cannot write the new post")
+		return
+	}
+
+	return
+}
+
+// render the archetype of the new post, the embedded one is used if the
+// repository has no default archetype
+func (post *Post) render(root, slug string, now time.Time) (text []byte, err error) {
+	archetype := DEFAULT_ARCHETYPE
+
+	name := post.Archetype
+	if name == "" {
+		name = ARCHETYPE_DEFAULT
+	}
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+
+	var data []byte
+	switch data, err = os.ReadFile(filepath.Join(root, ARCHETYPE_FOLDER, filepath.Clean(name))); {
+	case err == nil:
+		archetype = string(data)
+	case post.Archetype != "" || !os.IsNotExist(err):
+		err = fmt.Errorf("archetype %v: %v", name, err)
+		return
+	default:
+		// use the embedded archetype
+		err = nil
+	}
+
+	var tmpl *template.Template
+	if tmpl, err = template.New(name).Funcs(template.FuncMap{"slugify": config.Slugify}).Parse(archetype); err != nil {
+		err = fmt.Errorf("archetype %v: %v", name, err)
+		return
+	}
+
+	var buff bytes.Buffer
+	err = tmpl.Execute(&buff, struct {
+		Title string
+		Slug  string
+		Date  time.Time
+		Draft bool
+	}{
+		Title: post.Title,
+		Slug:  slug,
+		Date:  now,
+		Draft: post.Draft,
+	})
+
+	text = buff.Bytes()
+	return
+}
@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cmj0121/gitup/blog"
+	"github.com/cmj0121/gitup/config"
+)
+
+func TestInit(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "my-blog")
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	repo := &Init{Theme: true}
+	if err := repo.Create(root, now); err != nil {
+		t.Fatalf("cannot create the skeleton: %v", err)
+	}
+
+	conf := &config.Config{}
+	if err := conf.LoadFS(os.DirFS(root), "."); err != nil {
+		t.Fatalf("invalid generated config: %v", err)
+	}
+	if err := conf.Validate(os.DirFS(root)); err != nil {
+		t.Errorf("invalid generated skeleton: %v", err)
+	}
+	if conf.Brand != "my-blog" || conf.Theme != THEME_DEFAULT {
+		t.Errorf("unexpected brand %v and theme %v", conf.Brand, conf.Theme)
+	}
+
+	for _, name := range []string{"posts/hello-world.md", "themes/default/blog.htm", "archetypes/default.md"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("expect %v created: %v", name, err)
+		}
+	}
+
+	if err := repo.Create(root, now); err == nil {
+		t.Errorf("expect never overwrite the existing skeleton")
+	}
+}
+
+func TestNew(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	conf := &config.Config{Workdir: []string{"posts"}}
+
+	post := &Post{Title: "The New Post!", Draft: true}
+	path, err := post.Create(conf, root, now)
+	if err != nil {
+		t.Fatalf("cannot create the new post: %v", err)
+	}
+	if path != filepath.Join(root, "posts", "the-new-post.md") {
+		t.Errorf("unexpected path: %v", path)
+	}
+
+	text, _ := os.ReadFile(path) // nolint
+	meta, _, err := blog.SplitFrontMatter(text)
+	if err != nil || !meta.Draft || !meta.Date.Equal(now) {
+		t.Errorf("unexpected front matter %+v: %v", meta, err)
+	}
+
+	if _, err := post.Create(conf, root, now); err == nil {
+		t.Errorf("expect never overwrite the existing post")
+	}
+
+	// the archetype in the repository
+	archetype := "---\ntitle: {{ .Title }}\n---\n# {{ .Title }}\n> {{ .Slug }}\n"
+	os.MkdirAll(filepath.Join(root, ARCHETYPE_FOLDER), 0750)                                // nolint
+	os.WriteFile(filepath.Join(root, ARCHETYPE_FOLDER, "talk.md"), []byte(archetype), 0640) // nolint
+
+	post = &Post{Title: "My Talk", Workdir: "talks", Archetype: "talk"}
+	if path, err = post.Create(conf, root, now); err != nil {
+		t.Fatalf("cannot create the new post by archetype: %v", err)
+	}
+
+	text, _ = os.ReadFile(path) // nolint
+	if !strings.Contains(string(text), "title: My Talk") || !strings.HasSuffix(path, filepath.Join("talks", "my-talk.md")) {
+		t.Errorf("unexpected post %v: %s", path, text)
+	}
+
+	post.Archetype = "missing"
+	post.Title = "Other Talk"
+	if _, err := post.Create(conf, root, now); err == nil {
+		t.Errorf("expect the missing archetype fails")
+	}
+}